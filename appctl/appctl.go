@@ -4,8 +4,11 @@
 package appctl
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net"
 	"net/rpc"
 	"os"
@@ -13,8 +16,9 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 
-	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
 )
 
@@ -108,57 +112,162 @@ func prepareCallDbServer(method string, rundir string, args ...string) (string,
 	return sockpath, args, nil
 }
 
-func call(daemon appctlDaemon, method string, args ...string) string {
-	var rundir, sockpath string
-	var err error
+// Client calls unixctl methods on the daemons of one OVS/OVN instance.
+type Client struct {
+	ovsRundir     string
+	ovnRundir     string
+	ovsdbRundir   string
+	reachableLock sync.Mutex
+	reachable     map[string]bool
+}
+
+// Create a client for the daemons whose control sockets and pid files are
+// located in the given runtime directories.
+func NewClient(ovsRundir, ovnRundir, ovsdbRundir string) *Client {
+	return &Client{
+		ovsRundir:   ovsRundir,
+		ovnRundir:   ovnRundir,
+		ovsdbRundir: ovsdbRundir,
+		reachable:   make(map[string]bool),
+	}
+}
+
+type clientKey struct{}
+
+// Return a copy of ctx that carries c. The package level functions send their
+// calls to the daemons of the client found in their context.
+func WithClient(ctx context.Context, c *Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+func clientFrom(ctx context.Context) (*Client, error) {
+	if c, ok := ctx.Value(clientKey{}).(*Client); ok {
+		return c, nil
+	}
+	return nil, ErrNoClient
+}
+
+func (c *Client) setReachable(daemon appctlDaemon, ok bool) {
+	c.reachableLock.Lock()
+	c.reachable[string(daemon)] = ok
+	c.reachableLock.Unlock()
+}
+
+// Return whether each daemon could be reached during the last call made to
+// it. Daemons that were never called are not reported.
+func (c *Client) Reachable() map[string]bool {
+	c.reachableLock.Lock()
+	defer c.reachableLock.Unlock()
+	res := make(map[string]bool, len(c.reachable))
+	for daemon, ok := range c.reachable {
+		res[daemon] = ok
+	}
+	return res
+}
 
+func (c *Client) rundir(daemon appctlDaemon) string {
 	switch daemon {
 	case ovsVswitchd:
-		rundir = config.OvsRundir()
+		return c.ovsRundir
 	case ovnController:
-		rundir = config.OvnRundir()
+		return c.ovnRundir
 	case ovnNorthd:
-		rundir = config.OvnRundir()
+		return c.ovnRundir
 	case ovsDbServer:
-		rundir = config.OvsdbRundir()
+		return c.ovsdbRundir
 	default:
 		panic(fmt.Errorf("unknown daemon value: %v", daemon))
 	}
+}
 
-	if daemon == ovsDbServer {
-		sockpath, args, err = prepareCallDbServer(method, rundir, args...)
+// Return the pid of a daemon from its pid file or control socket name.
+func (c *Client) pid(daemon appctlDaemon) (int, error) {
+	rundir := c.rundir(daemon)
+	pidfile := filepath.Join(rundir, fmt.Sprintf("%s.pid", daemon))
+
+	// First try to get PID from .pid file
+	pid, err := getPidFromFile(pidfile)
+	if err != nil {
+		log.Debugf("Failed to read PID file %s: %s, trying to find PID from .ctl files", pidfile, err)
+		// If that fails, try to extract PID from .ctl files
+		pid, err = getPidFromCtlFiles(rundir, daemon)
 		if err != nil {
-			log.Errf("Failed to prepare call to %s: %s", daemon, err)
-			return ""
+			return 0, fmt.Errorf("%w: %w", ErrNotRunning, err)
 		}
-	} else {
-		pidfile := filepath.Join(rundir, fmt.Sprintf("%s.pid", daemon))
+	}
 
-		// First try to get PID from .pid file
-		pid, err := getPidFromFile(pidfile)
+	return pid, nil
+}
+
+// Resolve the unixctl socket path of a daemon. The arguments may be rewritten
+// for ovsdb-server.
+func (c *Client) socketPath(daemon appctlDaemon, method string, args []string) (string, []string, error) {
+	rundir := c.rundir(daemon)
+
+	if daemon == ovsDbServer {
+		sockpath, args, err := prepareCallDbServer(method, rundir, args...)
 		if err != nil {
-			log.Debugf("Failed to read PID file %s: %s, trying to find PID from .ctl files", pidfile, err)
-			// If that fails, try to extract PID from .ctl files
-			pid, err = getPidFromCtlFiles(rundir, daemon)
-			if err != nil {
-				log.Errf("Failed to get PID for %s: %s", daemon, err)
-				return ""
-			}
+			return "", args, fmt.Errorf("%w: %w", ErrNoSocket, err)
+		}
+		return sockpath, args, nil
+	}
+
+	pid, err := c.pid(daemon)
+	if err != nil {
+		return "", args, err
+	}
+
+	return filepath.Join(rundir, fmt.Sprintf("%s.%d.ctl", daemon, pid)), args, nil
+}
+
+func dial(ctx context.Context, sockpath string) (net.Conn, error) {
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, "unix", sockpath)
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("%w: %w", ErrNoSocket, err)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return nil, fmt.Errorf("%w: %w", ErrNotRunning, err)
+	default:
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return nil, err
 		}
+	}
+
+	return conn, nil
+}
+
+func call(ctx context.Context, daemon appctlDaemon, method string, args ...string) (string, error) {
+	c, err := clientFrom(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", daemon, err)
+	}
 
-		sockpath = filepath.Join(rundir, fmt.Sprintf("%s.%d.ctl", daemon, pid))
+	sockpath, args, err := c.socketPath(daemon, method, args)
+	if err != nil {
+		c.setReachable(daemon, false)
+		return "", fmt.Errorf("%s: %w", daemon, err)
 	}
 
-	conn, err := net.Dial("unix", sockpath)
+	conn, err := dial(ctx, sockpath)
 	if err != nil {
-		log.Errf("net.Dial: %s", err)
-		return ""
+		c.setReachable(daemon, false)
+		return "", fmt.Errorf("%s: %w", daemon, err)
 	}
 
 	client := rpc.NewClientWithCodec(NewClientCodec(conn))
 	defer func() {
 		err := client.Close()
-		if err != nil {
+		if err != nil && !errors.Is(err, rpc.ErrShutdown) {
 			log.Warningf("close: %s", err)
 		}
 	}()
@@ -170,26 +279,68 @@ func call(daemon appctlDaemon, method string, args ...string) string {
 	var reply string
 
 	log.Debugf("calling: %s %s", method, args)
-	if err = client.Call(method, args, &reply); err != nil {
-		log.Errf("call(%s): %s", method, err)
-		return ""
+	pending := client.Go(method, args, &reply, make(chan *rpc.Call, 1))
+
+	select {
+	case <-ctx.Done():
+		// the daemon is wedged, consider it unreachable
+		c.setReachable(daemon, false)
+		return "", fmt.Errorf("%s: %s: %w: %w", daemon, method, ErrTimeout, ctx.Err())
+	case <-pending.Done:
+	}
+
+	var serverErr rpc.ServerError
+	var netErr net.Error
+
+	switch err = pending.Error; {
+	case err == nil:
+	case errors.As(err, &serverErr):
+		c.setReachable(daemon, true)
+		return "", &RPCError{
+			Daemon:  string(daemon),
+			Method:  method,
+			Message: string(serverErr),
+		}
+	case errors.As(err, &netErr) && netErr.Timeout():
+		c.setReachable(daemon, false)
+		return "", fmt.Errorf("%s: %s: %w: %w", daemon, method, ErrTimeout, err)
+	default:
+		c.setReachable(daemon, false)
+		return "", fmt.Errorf("%s: %s: %w", daemon, method, err)
 	}
 
-	return reply
+	c.setReachable(daemon, true)
+
+	return reply, nil
+}
+
+// Call a method on the ovs-vswitchd unixctl socket of the client found in the
+// context. The context deadline, if any, bounds both connecting to the socket
+// and waiting for the reply.
+func OvsVSwitchd(ctx context.Context, method string, args ...string) (string, error) {
+	return call(ctx, ovsVswitchd, method, args...)
 }
 
-func OvsVSwitchd(method string, args ...string) string {
-	return call(ovsVswitchd, method, args...)
+// Call a method on the ovn-controller unixctl socket.
+func OvnController(ctx context.Context, method string, args ...string) (string, error) {
+	return call(ctx, ovnController, method, args...)
 }
 
-func OvnController(method string, args ...string) string {
-	return call(ovnController, method, args...)
+// Call a method on the ovn-northd unixctl socket.
+func OvnNorthd(ctx context.Context, method string, args ...string) (string, error) {
+	return call(ctx, ovnNorthd, method, args...)
 }
 
-func OvnNorthd(method string, args ...string) string {
-	return call(ovnNorthd, method, args...)
+// Call a method on the OVN NB/SB ovsdb-server unixctl socket.
+func OvsDbServer(ctx context.Context, method string, args ...string) (string, error) {
+	return call(ctx, ovsDbServer, method, args...)
 }
 
-func OvsDbServer(method string, args ...string) string {
-	return call(ovsDbServer, method, args...)
+// Return the pid of ovs-vswitchd.
+func OvsVSwitchdPid(ctx context.Context) (int, error) {
+	c, err := clientFrom(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return c.pid(ovsVswitchd)
 }
@@ -0,0 +1,113 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+// Package appctltest provides fake OVS/OVN daemons that answer unixctl calls
+// with canned replies so that collectors can be tested without a running
+// instance.
+package appctltest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
+)
+
+// Replies maps unixctl commands to the text returned by a fake daemon. Keys
+// are the method followed by its arguments, separated by spaces, e.g.
+// "dpctl/ct-stats-show -m system@ovs-system". Commands missing from the map
+// are answered with an error, like unknown commands are by real daemons.
+type Replies map[string]string
+
+// Start fake daemons in a temporary runtime directory. daemons is indexed by
+// daemon name: "ovs-vswitchd", "ovn-controller" or "ovn-northd". Return a
+// context whose appctl calls are sent to them. The daemons are stopped when
+// the test ends.
+func Start(t testing.TB, daemons map[string]Replies) context.Context {
+	t.Helper()
+
+	// t.TempDir() paths can exceed the maximum unix socket path length
+	rundir, err := os.MkdirTemp("", "appctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(rundir) })
+
+	pid := os.Getpid()
+
+	for name, replies := range daemons {
+		pidfile := filepath.Join(rundir, name+".pid")
+		if err := os.WriteFile(pidfile, []byte(fmt.Sprintf("%d\n", pid)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		sockpath := filepath.Join(rundir, fmt.Sprintf("%s.%d.ctl", name, pid))
+		l, err := net.Listen("unix", sockpath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { l.Close() })
+		go serve(l, replies)
+	}
+
+	c := appctl.NewClient(rundir, rundir, rundir)
+
+	return appctl.WithClient(context.Background(), c)
+}
+
+func serve(l net.Listener, replies Replies) {
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		} else if err != nil {
+			continue
+		}
+		go handle(conn, replies)
+	}
+}
+
+type request struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	Id     any      `json:"id"`
+}
+
+type response struct {
+	Id     any     `json:"id"`
+	Result *string `json:"result"`
+	Error  *string `json:"error"`
+}
+
+func handle(conn net.Conn, replies Replies) {
+	defer conn.Close()
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+
+	for {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+
+		resp := response{Id: req.Id}
+		command := strings.Join(append([]string{req.Method}, req.Params...), " ")
+		if reply, ok := replies[command]; ok {
+			resp.Result = &reply
+		} else {
+			msg := fmt.Sprintf("%q is not a valid command", req.Method)
+			resp.Error = &msg
+		}
+
+		if err := enc.Encode(&resp); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package appctl
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// The daemon pid could not be determined or nothing is listening on
+	// its control socket.
+	ErrNotRunning = errors.New("daemon not running")
+	// The daemon control socket does not exist.
+	ErrNoSocket = errors.New("control socket not found")
+	// The call did not complete before the context deadline.
+	ErrTimeout = errors.New("timeout")
+	// No Client was attached to the context with WithClient.
+	ErrNoClient = errors.New("no appctl client in context")
+)
+
+// RPCError is returned when the daemon replied with an error, e.g. when the
+// method does not exist or its arguments are invalid.
+type RPCError struct {
+	Daemon  string
+	Method  string
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Daemon, e.Method, e.Message)
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/rpc"
+	"strings"
 	"sync"
 )
 
@@ -80,10 +81,7 @@ func (c *clientCodec) ReadResponseHeader(r *rpc.Response) error {
 	r.Error = ""
 	r.Seq = c.resp.Id
 	if c.resp.Error != nil || c.resp.Result == nil {
-		x, ok := c.resp.Error.(string)
-		if !ok {
-			return fmt.Errorf("invalid error %v", c.resp.Error)
-		}
+		x := errorString(c.resp.Error)
 		if x == "" {
 			x = "unspecified error"
 		}
@@ -92,6 +90,34 @@ func (c *clientCodec) ReadResponseHeader(r *rpc.Response) error {
 	return nil
 }
 
+// Format a JSON-RPC error object. The daemons usually reply with a plain
+// string but some send objects such as {"error": "...", "details": "..."}.
+// Returning an error from ReadResponseHeader would tear down the connection,
+// convert them to strings instead.
+func errorString(e any) string {
+	switch x := e.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case map[string]any:
+		var parts []string
+		for _, key := range []string{"error", "message", "details"} {
+			if v, ok := x[key].(string); ok && v != "" {
+				parts = append(parts, v)
+			}
+		}
+		if len(parts) > 0 {
+			return strings.Join(parts, ": ")
+		}
+	}
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Sprintf("%v", e)
+	}
+	return string(buf)
+}
+
 func (c *clientCodec) ReadResponseBody(x any) error {
 	if x == nil {
 		return nil
@@ -0,0 +1,325 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package acl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/skydive-project/goloxi/of10"
+
+	libovsdb "github.com/ovn-org/libovsdb/ovsdb"
+)
+
+type Collector struct{}
+
+func (Collector) Name() string {
+	return "acl"
+}
+
+func (Collector) Metrics() []lib.Metric {
+	return []lib.Metric{packetsMetric, bytesMetric}
+}
+
+// Logical switch pipeline stages where ovn-northd generates the logical flows
+// of the ACLs with the first 8 hex digits of the ACL UUID in the stage-hint
+// external id. Older OVN versions use the stages without the _eval suffix.
+var aclStages = []string{
+	"ls_in_acl",
+	"ls_in_acl_eval",
+	"ls_in_acl_after_lb",
+	"ls_in_acl_after_lb_eval",
+	"ls_out_acl",
+	"ls_out_acl_eval",
+}
+
+// Return the verdict of an ACL action.
+func verdict(action string) string {
+	switch action {
+	case "allow", "allow-related", "allow-stateless":
+		return "allow"
+	case "drop", "reject":
+		return "drop"
+	}
+	return action
+}
+
+type acl struct {
+	uuid      string
+	rule      string
+	direction string
+	action    string
+}
+
+type aclStats struct {
+	packets uint64
+	bytes   uint64
+}
+
+// Return the value of a string map column or an empty string.
+func mapValue(row libovsdb.Row, column, key string) string {
+	if m, ok := row[column].(libovsdb.OvsMap); ok {
+		if v, ok := m.GoMap[key].(string); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// Return the value of a UUID column or an empty string.
+func uuidValue(row libovsdb.Row, column string) string {
+	if u, ok := row[column].(libovsdb.UUID); ok {
+		return u.GoUUID
+	}
+	return ""
+}
+
+// Return the openflow cookie of a logical flow, i.e. the first 32 bits of
+// its UUID.
+func lflowCookie(uuid string) (uint32, bool) {
+	if len(uuid) < 8 {
+		return 0, false
+	}
+	cookie, err := strconv.ParseUint(uuid[:8], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(cookie), true
+}
+
+// Return the ACLs from the northbound database indexed by the first 8 hex
+// digits of their UUID, as found in the stage-hint of their logical flows.
+func getACLs(ctx context.Context, t *lib.Target) (map[string][]*acl, error) {
+	rows, err := t.Nbdb.Select(ctx, "ACL",
+		[]string{"_uuid", "action", "direction", "external_ids"})
+	if err != nil {
+		return nil, err
+	}
+	acls := make(map[string][]*acl)
+	for _, row := range rows {
+		uuid := uuidValue(row, "_uuid")
+		if len(uuid) < 8 {
+			continue
+		}
+		a := &acl{
+			uuid: uuid,
+			rule: mapValue(row, "external_ids", "neutron:security_group_rule_id"),
+		}
+		a.action, _ = row["action"].(string)
+		a.direction, _ = row["direction"].(string)
+		acls[uuid[:8]] = append(acls[uuid[:8]], a)
+	}
+	return acls, nil
+}
+
+// Logical table of the southbound database.
+type lflowTable struct {
+	pipeline string
+	id       int
+}
+
+// Return the logical tables of the ACL stages.
+func getTables(ctx context.Context, db *ovsdb.Client) (map[lflowTable]bool, error) {
+	tables := make(map[lflowTable]bool)
+	for _, stage := range aclStages {
+		rows, err := db.Select(ctx, "Logical_Flow",
+			[]string{"pipeline", "table_id"},
+			libovsdb.NewCondition("external_ids", libovsdb.ConditionIncludes,
+				libovsdb.OvsMap{GoMap: map[any]any{"stage-name": stage}}))
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			pipeline, _ := row["pipeline"].(string)
+			id, _ := row["table_id"].(float64)
+			tables[lflowTable{pipeline: pipeline, id: int(id)}] = true
+		}
+	}
+	return tables, nil
+}
+
+// Integration bridge rules of a target resolved to the ACLs that generated
+// them. Building it requires selecting all ACLs and the logical flows of
+// their tables. It is only rebuilt when unknown cookies are found.
+type mapping struct {
+	lock sync.Mutex
+	// integration bridge tables of the ACL stages
+	tables []uint8
+	// ACL of the logical flows of these tables indexed by cookie. Logical
+	// flows that do not come from exactly one ACL map to nil.
+	cookies map[uint32]*acl
+}
+
+func (m *mapping) refresh(ctx context.Context, t *lib.Target) error {
+	acls, err := getACLs(ctx, t)
+	if err != nil {
+		return err
+	}
+	sb, err := t.Sbdb(ctx)
+	if err != nil {
+		return err
+	}
+	tables, err := getTables(ctx, sb)
+	if err != nil {
+		return err
+	}
+
+	var ofTables []uint8
+	cookies := make(map[uint32]*acl)
+	ambiguous := make(map[uint32]bool)
+
+	for table := range tables {
+		switch table.pipeline {
+		case "ingress":
+			ofTables = append(ofTables, t.OvnTables.LogIngressPipeline+uint8(table.id))
+		case "egress":
+			ofTables = append(ofTables, t.OvnTables.LogEgressPipeline+uint8(table.id))
+		default:
+			continue
+		}
+		// logical router stages share the same tables, select all
+		// logical flows to recognize their cookies as well
+		rows, err := sb.Select(ctx, "Logical_Flow",
+			[]string{"_uuid", "external_ids"},
+			libovsdb.NewCondition("pipeline", libovsdb.ConditionEqual, table.pipeline),
+			libovsdb.NewCondition("table_id", libovsdb.ConditionEqual, table.id))
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			cookie, ok := lflowCookie(uuidValue(row, "_uuid"))
+			if !ok {
+				continue
+			}
+			var a *acl
+			if slices.Contains(aclStages, mapValue(row, "external_ids", "stage-name")) {
+				// the hint must be the prefix of a single ACL UUID
+				hint := mapValue(row, "external_ids", "stage-hint")
+				if matches := acls[hint]; len(matches) == 1 {
+					a = matches[0]
+				}
+			}
+			if prev, ok := cookies[cookie]; ok && prev != a {
+				ambiguous[cookie] = true
+			}
+			cookies[cookie] = a
+		}
+	}
+	for cookie := range ambiguous {
+		cookies[cookie] = nil
+	}
+	slices.Sort(ofTables)
+
+	m.tables = ofTables
+	m.cookies = cookies
+
+	return nil
+}
+
+// Return the counters of the ACL tables rules summed per cookie and whether
+// some cookies are missing from the mapping.
+func (m *mapping) dump(ctx context.Context, t *lib.Target) (map[uint32]*aclStats, bool, error) {
+	flows := make(map[uint32]*aclStats)
+	unknown := false
+	for _, table := range m.tables {
+		err := openflow.WalkFlowStats(ctx, t.IntBridge(), table, func(f *of10.NiciraFlowStats) error {
+			cookie := uint32(f.GetCookie())
+			if cookie == 0 {
+				return nil
+			}
+			if _, ok := m.cookies[cookie]; !ok {
+				unknown = true
+			}
+			s, ok := flows[cookie]
+			if !ok {
+				s = new(aclStats)
+				flows[cookie] = s
+			}
+			s.packets += f.GetPacketCount()
+			s.bytes += f.GetByteCount()
+			return nil
+		})
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: WalkFlowStats: %w", t.IntBridge(), err)
+		}
+	}
+	return flows, unknown, nil
+}
+
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if !lib.MetricSets(ctx).Has(config.METRICS_DEBUG) {
+		return nil
+	}
+	t := lib.TargetFrom(ctx)
+	if t == nil {
+		return errors.New("no target in context")
+	}
+
+	m := t.CollectorState("acl", func() any { return new(mapping) }).(*mapping)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.cookies == nil {
+		if err := m.refresh(ctx, t); err != nil {
+			return err
+		}
+	}
+	flows, unknown, err := m.dump(ctx, t)
+	if err != nil {
+		return err
+	}
+	if unknown {
+		// logical flows were added since the last refresh
+		if err := m.refresh(ctx, t); err != nil {
+			return err
+		}
+		if flows, _, err = m.dump(ctx, t); err != nil {
+			return err
+		}
+		// do not refresh again for rules that were not generated by
+		// a logical flow of the ACL tables
+		for cookie := range flows {
+			if _, ok := m.cookies[cookie]; !ok {
+				m.cookies[cookie] = nil
+			}
+		}
+	}
+
+	// only report the ACLs that have openflow rules on this chassis
+	acls := make(map[*acl]*aclStats)
+	for cookie, f := range flows {
+		a := m.cookies[cookie]
+		if a == nil {
+			continue
+		}
+		s, ok := acls[a]
+		if !ok {
+			s = new(aclStats)
+			acls[a] = s
+		}
+		s.packets += f.packets
+		s.bytes += f.bytes
+	}
+
+	for a, s := range acls {
+		labels := []string{a.uuid, a.rule, a.direction, a.action, verdict(a.action)}
+		ch <- prometheus.MustNewConstMetric(
+			packetsMetric.Desc(), packetsMetric.ValueType,
+			float64(s.packets), labels...)
+		ch <- prometheus.MustNewConstMetric(
+			bytesMetric.Desc(), bytesMetric.ValueType,
+			float64(s.bytes), labels...)
+	}
+
+	return nil
+}
@@ -0,0 +1,245 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package acl
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib/libtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow/openflowtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovsdbtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/sb"
+	"github.com/ovn-org/libovsdb/model"
+	"github.com/ovn-org/libovsdb/ovsdb"
+)
+
+// Subset of the OVN_Northbound ACL table.
+type nbACL struct {
+	UUID        string            `ovsdb:"_uuid"`
+	Action      string            `ovsdb:"action"`
+	Direction   string            `ovsdb:"direction"`
+	ExternalIDs map[string]string `ovsdb:"external_ids"`
+}
+
+const nbSchema = `{
+	"name": "OVN_Northbound",
+	"version": "7.3.0",
+	"tables": {
+		"ACL": {
+			"columns": {
+				"action": {"type": "string"},
+				"direction": {"type": "string"},
+				"external_ids": {"type": {"key": "string", "value": "string",
+					"min": 0, "max": "unlimited"}}
+			},
+			"isRoot": true
+		}
+	}
+}`
+
+// Subset of the OVN_Southbound Logical_Flow table.
+type sbLogicalFlow struct {
+	UUID        string            `ovsdb:"_uuid"`
+	Pipeline    string            `ovsdb:"pipeline"`
+	TableID     int               `ovsdb:"table_id"`
+	ExternalIDs map[string]string `ovsdb:"external_ids"`
+}
+
+// Logical_Flow columns that are not part of the ovsdb/sb subset.
+const lflowColumns = `{
+	"table_id": {"type": "integer"},
+	"external_ids": {"type": {"key": "string", "value": "string",
+		"min": 0, "max": "unlimited"}}
+}`
+
+func parse(t *testing.T, data string, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func serve(t *testing.T, s ovsdb.DatabaseSchema, table string, m model.Model, rows []model.Model) string {
+	t.Helper()
+
+	clientModel, err := model.NewClientDBModel(s.Name, map[string]model.Model{table: m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ovsdbtest.Serve(t, s, clientModel, rows...)
+}
+
+const (
+	acl1 = "a1a1a1a1-1c2d-4e5f-8a9b-000000000001"
+	acl2 = "b2b2b2b2-1c2d-4e5f-8a9b-000000000002"
+	// same stage-hint as acl1
+	acl3 = "a1a1a1a1-1c2d-4e5f-8a9b-000000000003"
+)
+
+var acls = []model.Model{
+	&nbACL{
+		UUID:      acl1,
+		Action:    "allow-related",
+		Direction: "to-lport",
+		ExternalIDs: map[string]string{
+			"neutron:security_group_rule_id": "0f6e2a3c-5b4d-4e7f-9a8b-1c2d3e4f5a6b",
+		},
+	},
+	&nbACL{UUID: acl2, Action: "drop", Direction: "from-lport"},
+}
+
+var lflows = []model.Model{
+	&sbLogicalFlow{
+		UUID:     "11111111-1c2d-4e5f-8a9b-000000000001",
+		Pipeline: "egress",
+		TableID:  4,
+		ExternalIDs: map[string]string{
+			"stage-name": "ls_out_acl_eval",
+			"stage-hint": "a1a1a1a1",
+		},
+	},
+	&sbLogicalFlow{
+		UUID:     "22222222-1c2d-4e5f-8a9b-000000000002",
+		Pipeline: "ingress",
+		TableID:  8,
+		ExternalIDs: map[string]string{
+			"stage-name": "ls_in_acl_eval",
+			"stage-hint": "b2b2b2b2",
+		},
+	},
+	// default ACL stage flow without hint
+	&sbLogicalFlow{
+		UUID:        "33333333-1c2d-4e5f-8a9b-000000000003",
+		Pipeline:    "ingress",
+		TableID:     8,
+		ExternalIDs: map[string]string{"stage-name": "ls_in_acl_eval"},
+	},
+	// logical router stage in the same table
+	&sbLogicalFlow{
+		UUID:        "44444444-1c2d-4e5f-8a9b-000000000004",
+		Pipeline:    "ingress",
+		TableID:     8,
+		ExternalIDs: map[string]string{"stage-name": "lr_in_ip_routing"},
+	},
+}
+
+var flows = []openflowtest.Flow{
+	// ls_out_acl_eval
+	{Table: 46, Cookie: 0x11111111, Packets: 10, Bytes: 1000},
+	{Table: 46, Cookie: 0x11111111, Packets: 5, Bytes: 500},
+	{Table: 46, Cookie: 0, Packets: 1, Bytes: 100},
+	// ls_in_acl_eval
+	{Table: 16, Cookie: 0x22222222, Packets: 3, Bytes: 180},
+	{Table: 16, Cookie: 0x33333333, Packets: 7, Bytes: 700},
+	{Table: 16, Cookie: 0x44444444, Packets: 9, Bytes: 900},
+	// not an ACL table, not dumped
+	{Table: 30, Cookie: 0x11111111, Packets: 100, Bytes: 10000},
+}
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name   string
+		sets   config.MetricSet
+		acls   []model.Model
+		lflows []model.Model
+		flows  []openflowtest.Flow
+		want   []string
+	}{
+		{
+			name:   "acl tables",
+			sets:   config.METRICS_DEBUG,
+			acls:   acls,
+			lflows: lflows,
+			flows:  flows,
+			want: []string{
+				`ovnc_acl_bytes{acl="a1a1a1a1-1c2d-4e5f-8a9b-000000000001",action="allow-related",direction="to-lport",security_group_rule="0f6e2a3c-5b4d-4e7f-9a8b-1c2d3e4f5a6b",verdict="allow"} 1500`,
+				`ovnc_acl_bytes{acl="b2b2b2b2-1c2d-4e5f-8a9b-000000000002",action="drop",direction="from-lport",security_group_rule="",verdict="drop"} 180`,
+				`ovnc_acl_packets{acl="a1a1a1a1-1c2d-4e5f-8a9b-000000000001",action="allow-related",direction="to-lport",security_group_rule="0f6e2a3c-5b4d-4e7f-9a8b-1c2d3e4f5a6b",verdict="allow"} 15`,
+				`ovnc_acl_packets{acl="b2b2b2b2-1c2d-4e5f-8a9b-000000000002",action="drop",direction="from-lport",security_group_rule="",verdict="drop"} 3`,
+			},
+		},
+		{
+			name: "ambiguous stage hint",
+			sets: config.METRICS_DEBUG,
+			acls: append(slices.Clone(acls),
+				&nbACL{UUID: acl3, Action: "allow", Direction: "to-lport"}),
+			lflows: lflows,
+			flows:  flows,
+			want: []string{
+				`ovnc_acl_bytes{acl="b2b2b2b2-1c2d-4e5f-8a9b-000000000002",action="drop",direction="from-lport",security_group_rule="",verdict="drop"} 180`,
+				`ovnc_acl_packets{acl="b2b2b2b2-1c2d-4e5f-8a9b-000000000002",action="drop",direction="from-lport",security_group_rule="",verdict="drop"} 3`,
+			},
+		},
+		{
+			name:   "unknown cookie",
+			sets:   config.METRICS_DEBUG,
+			acls:   acls,
+			lflows: lflows,
+			flows: append(slices.Clone(flows),
+				openflowtest.Flow{Table: 16, Cookie: 0x55555555, Packets: 1, Bytes: 60}),
+			want: []string{
+				`ovnc_acl_bytes{acl="a1a1a1a1-1c2d-4e5f-8a9b-000000000001",action="allow-related",direction="to-lport",security_group_rule="0f6e2a3c-5b4d-4e7f-9a8b-1c2d3e4f5a6b",verdict="allow"} 1500`,
+				`ovnc_acl_bytes{acl="b2b2b2b2-1c2d-4e5f-8a9b-000000000002",action="drop",direction="from-lport",security_group_rule="",verdict="drop"} 180`,
+				`ovnc_acl_packets{acl="a1a1a1a1-1c2d-4e5f-8a9b-000000000001",action="allow-related",direction="to-lport",security_group_rule="0f6e2a3c-5b4d-4e7f-9a8b-1c2d3e4f5a6b",verdict="allow"} 15`,
+				`ovnc_acl_packets{acl="b2b2b2b2-1c2d-4e5f-8a9b-000000000002",action="drop",direction="from-lport",security_group_rule="",verdict="drop"} 3`,
+			},
+		},
+		{
+			name:   "no acl",
+			sets:   config.METRICS_DEBUG,
+			lflows: lflows[3:],
+			flows:  flows,
+			want:   nil,
+		},
+		{
+			name:   "default set",
+			sets:   config.METRICS_DEFAULT,
+			acls:   acls,
+			lflows: lflows,
+			flows:  flows,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var nb ovsdb.DatabaseSchema
+			parse(t, nbSchema, &nb)
+			sbSchema := sb.Schema()
+			var columns map[string]*ovsdb.ColumnSchema
+			parse(t, lflowColumns, &columns)
+			maps.Copy(sbSchema.Tables["Logical_Flow"].Columns, columns)
+
+			target := lib.NewTarget(config.Target{
+				OvnNbRemote: serve(t, nb, "ACL", &nbACL{}, tt.acls),
+				OvnSbRemote: serve(t, sbSchema, "Logical_Flow", &sbLogicalFlow{}, tt.lflows),
+			}, lib.Settings{OvnTables: openflow.DefaultOvnTables})
+			target.Openflow = openflowtest.NewClient(t, map[string][]openflowtest.Flow{
+				"br-int": tt.flows,
+			})
+			t.Cleanup(target.Close)
+			ctx := lib.WithMetricSets(target.Bind(context.Background()), tt.sets)
+
+			// the second scrape uses the cached mapping
+			for i := 0; i < 2; i++ {
+				got, err := libtest.Collect(ctx, Collector{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("got:\n%s\nwant:\n%s",
+						strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package acl
+
+import (
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var labels = []string{"acl", "security_group_rule", "direction", "action", "verdict"}
+
+var packetsMetric = lib.Metric{
+	Name:        "ovnc_acl_packets",
+	Description: "The number of packets that matched the openflow rules of an OVN ACL on the integration bridge.",
+	Labels:      labels,
+	ValueType:   prometheus.CounterValue,
+	Set:         config.METRICS_DEBUG,
+}
+
+var bytesMetric = lib.Metric{
+	Name:        "ovnc_acl_bytes",
+	Description: "The number of bytes that matched the openflow rules of an OVN ACL on the integration bridge.",
+	Labels:      labels,
+	ValueType:   prometheus.CounterValue,
+	Set:         config.METRICS_DEBUG,
+}
@@ -0,0 +1,83 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package bfd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Collector struct{}
+
+func (Collector) Name() string {
+	return "bfd"
+}
+
+func (Collector) Metrics() []lib.Metric {
+	var res []lib.Metric
+	for _, m := range metrics {
+		res = append(res, m.Metric)
+	}
+	return res
+}
+
+// Return the remote chassis name from an ovn-chassis-id external id. Recent
+// OVN versions use "<chassis>@<remote_ip>%<local_ip>", older ones only the
+// chassis name.
+func chassisName(externalIDs map[string]string) string {
+	id, ok := externalIDs["ovn-chassis-id"]
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(id, "@")
+	return name
+}
+
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	var ports []ovs.Port
+	var ifaces []ovs.Interface
+
+	err := ovsdb.List(ctx, &ports)
+	if err != nil {
+		return fmt.Errorf("db.List(Port): %w", err)
+	}
+	err = ovsdb.List(ctx, &ifaces)
+	if err != nil {
+		return fmt.Errorf("db.List(Interface): %w", err)
+	}
+
+	// ovn-controller sets ovn-chassis-id on the tunnel ports
+	ifacePort := make(map[string]*ovs.Port)
+	for p := range ports {
+		for _, i := range ports[p].Interfaces {
+			ifacePort[i] = &ports[p]
+		}
+	}
+
+	for _, i := range ifaces {
+		if len(i.BFDStatus) == 0 {
+			continue
+		}
+		chassis := chassisName(i.ExternalIDs)
+		if p, ok := ifacePort[i.UUID]; ok && chassis == "" {
+			chassis = chassisName(p.ExternalIDs)
+		}
+		labels := []string{i.Name, i.Options["remote_ip"], chassis}
+
+		for _, m := range metrics {
+			if lib.MetricSets(ctx).Has(m.Set) {
+				ch <- prometheus.MustNewConstMetric(m.Desc(),
+					m.ValueType, m.GetValue(i.BFDStatus), labels...)
+			}
+		}
+	}
+
+	return nil
+}
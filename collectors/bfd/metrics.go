@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package bfd
+
+import (
+	"strconv"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Metric struct {
+	lib.Metric
+	GetValue func(status map[string]string) float64
+}
+
+var commonLabels = []string{"interface", "remote_ip", "remote_chassis"}
+
+func stateValue(state string) float64 {
+	switch state {
+	case "admin_down":
+		return 0
+	case "down":
+		return 1
+	case "init":
+		return 2
+	case "up":
+		return 3
+	}
+	return -1
+}
+
+// Diagnostic codes defined in RFC 5880 section 4.1, as printed by OVS.
+var diagnostics = map[string]float64{
+	"No Diagnostic":                  0,
+	"Control Detection Time Expired": 1,
+	"Echo Function Failed":           2,
+	"Neighbor Signaled Session Down": 3,
+	"Forwarding Plane Reset":         4,
+	"Path Down":                      5,
+	"Concatenated Path Down":         6,
+	"Administratively Down":          7,
+	"Reverse Concatenated Path Down": 8,
+}
+
+func diagnosticValue(diag string) float64 {
+	if val, ok := diagnostics[diag]; ok {
+		return val
+	}
+	return -1
+}
+
+var metrics = []Metric{
+	{
+		lib.Metric{
+			Name:        "ovs_bfd_state",
+			Description: "The state of the local BFD session. Possible values are: admin_down(0), down(1), init(2), up(3) or unknown(-1).",
+			Labels:      commonLabels,
+			ValueType:   prometheus.GaugeValue,
+			Set:         config.METRICS_BASE,
+		},
+		func(status map[string]string) float64 {
+			return stateValue(status["state"])
+		},
+	},
+	{
+		lib.Metric{
+			Name:        "ovs_bfd_remote_state",
+			Description: "The state of the remote BFD session. Possible values are: admin_down(0), down(1), init(2), up(3) or unknown(-1).",
+			Labels:      commonLabels,
+			ValueType:   prometheus.GaugeValue,
+			Set:         config.METRICS_BASE,
+		},
+		func(status map[string]string) float64 {
+			return stateValue(status["remote_state"])
+		},
+	},
+	{
+		lib.Metric{
+			Name:        "ovs_bfd_forwarding",
+			Description: "Is BFD reporting the tunnel as usable for forwarding traffic.",
+			Labels:      commonLabels,
+			ValueType:   prometheus.GaugeValue,
+			Set:         config.METRICS_BASE,
+		},
+		func(status map[string]string) float64 {
+			if status["forwarding"] == "true" {
+				return 1
+			}
+			return 0
+		},
+	},
+	{
+		lib.Metric{
+			Name:        "ovs_bfd_diagnostic",
+			Description: "The RFC 5880 diagnostic code of the last local BFD session state change (0 means no diagnostic, -1 unknown).",
+			Labels:      commonLabels,
+			ValueType:   prometheus.GaugeValue,
+			Set:         config.METRICS_ERRORS,
+		},
+		func(status map[string]string) float64 {
+			return diagnosticValue(status["diagnostic"])
+		},
+	},
+	{
+		lib.Metric{
+			Name:        "ovs_bfd_remote_diagnostic",
+			Description: "The RFC 5880 diagnostic code reported by the remote BFD session (0 means no diagnostic, -1 unknown).",
+			Labels:      commonLabels,
+			ValueType:   prometheus.GaugeValue,
+			Set:         config.METRICS_ERRORS,
+		},
+		func(status map[string]string) float64 {
+			return diagnosticValue(status["remote_diagnostic"])
+		},
+	},
+	{
+		lib.Metric{
+			Name:        "ovs_bfd_flap_count",
+			Description: "The number of times the BFD session changed its forwarding state.",
+			Labels:      commonLabels,
+			ValueType:   prometheus.CounterValue,
+			Set:         config.METRICS_ERRORS,
+		},
+		func(status map[string]string) float64 {
+			val, _ := strconv.ParseFloat(status["flap_count"], 64)
+			return val
+		},
+	},
+}
@@ -0,0 +1,306 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package bond
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Collector struct{}
+
+func (Collector) Name() string {
+	return "bond"
+}
+
+func (Collector) Metrics() []lib.Metric {
+	res := []lib.Metric{
+		infoMetric, lacpNegotiatedMetric,
+		memberEnabledMetric, memberMayEnableMetric, memberActiveMetric,
+		memberLacpCurrentMetric, memberLacpAttachedMetric,
+		memberActorStateMetric, memberPartnerStateMetric,
+	}
+	for _, m := range lacpStats {
+		res = append(res, m)
+	}
+	return res
+}
+
+var (
+	// "---- bond0 ----"
+	bondRe = regexp.MustCompile(`^---- (\S+) ----$`)
+	// "bond_mode: balance-tcp"
+	bondModeRe = regexp.MustCompile(`^bond_mode: (\S+)$`)
+	// "lacp_status: negotiated"
+	lacpStatusRe = regexp.MustCompile(`^lacp_status: (\S+)$`)
+	// "member p0: enabled" (older versions print "slave" instead of "member")
+	memberRe = regexp.MustCompile(`^(?:member|slave) (\S+): (enabled|disabled)$`)
+	// "  active member"
+	activeRe = regexp.MustCompile(`^\s+active (?:member|slave)$`)
+	// "  may_enable: true"
+	mayEnableRe = regexp.MustCompile(`^\s+may_enable: (true|false)$`)
+	// "member: p0: current attached"
+	lacpMemberRe = regexp.MustCompile(`^(?:member|slave): (\S+): (.*)$`)
+	// "  actor state: activity aggregation synchronized collecting distributing"
+	actorStateRe = regexp.MustCompile(`^\s+actor state:(.*)$`)
+	// "  partner state: activity aggregation synchronized collecting distributing"
+	partnerStateRe = regexp.MustCompile(`^\s+partner state:(.*)$`)
+	// "---- bond0 statistics ----"
+	statsBondRe = regexp.MustCompile(`^---- (\S+) statistics ----$`)
+	// "member: p0:"
+	statsMemberRe = regexp.MustCompile(`^(?:member|slave): (\S+):$`)
+	// "  RX Bad PDUs: 0"
+	statsRe = regexp.MustCompile(`^\s+([^:]+): (\d+)$`)
+)
+
+// LACP actor and partner state flags, as printed by lacp/show.
+var lacpFlags = []string{
+	"activity", "timeout", "aggregation", "synchronized",
+	"collecting", "distributing", "defaulted", "expired",
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	var bridges []ovs.Bridge
+	var ports []ovs.Port
+
+	err := ovsdb.List(ctx, &bridges)
+	if err != nil {
+		return fmt.Errorf("db.List(Bridge): %w", err)
+	}
+	err = ovsdb.List(ctx, &ports)
+	if err != nil {
+		return fmt.Errorf("db.List(Port): %w", err)
+	}
+
+	portBridge := make(map[string]string)
+	for _, br := range bridges {
+		for _, p := range br.Ports {
+			portBridge[p] = br.Name
+		}
+	}
+	// bonds are ports with more than one interface
+	bondBridge := make(map[string]string)
+	for _, p := range ports {
+		if len(p.Interfaces) > 1 {
+			bondBridge[p.Name] = portBridge[p.UUID]
+		}
+	}
+	if len(bondBridge) == 0 {
+		return nil
+	}
+
+	metric := func(m *lib.Metric, val float64, labels ...string) {
+		if lib.MetricSets(ctx).Has(m.Set) {
+			ch <- prometheus.MustNewConstMetric(m.Desc(), m.ValueType, val, labels...)
+		}
+	}
+
+	if err := collectBonds(ctx, bondBridge, metric); err != nil {
+		return err
+	}
+	if err := collectLacp(ctx, bondBridge, metric); err != nil {
+		return err
+	}
+	return collectLacpStats(ctx, bondBridge, metric)
+}
+
+type metricFunc func(m *lib.Metric, val float64, labels ...string)
+
+type bondMember struct {
+	name      string
+	enabled   bool
+	mayEnable bool
+	active    bool
+}
+
+type bondStatus struct {
+	mode       string
+	lacpStatus string
+	members    []*bondMember
+}
+
+func collectBonds(ctx context.Context, bondBridge map[string]string, metric metricFunc) error {
+	buf, err := appctl.OvsVSwitchd(ctx, "bond/show")
+	if err != nil {
+		return err
+	}
+
+	bonds := make(map[string]*bondStatus)
+	var bond *bondStatus
+	var member *bondMember
+
+	scanner := bufio.NewScanner(strings.NewReader(buf))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := bondRe.FindStringSubmatch(line); m != nil {
+			bond = new(bondStatus)
+			bonds[m[1]] = bond
+			member = nil
+			continue
+		}
+		if bond == nil {
+			continue
+		}
+		if m := bondModeRe.FindStringSubmatch(line); m != nil {
+			bond.mode = m[1]
+		} else if m := lacpStatusRe.FindStringSubmatch(line); m != nil {
+			bond.lacpStatus = m[1]
+		} else if m := memberRe.FindStringSubmatch(line); m != nil {
+			member = &bondMember{name: m[1], enabled: m[2] == "enabled"}
+			bond.members = append(bond.members, member)
+		} else if member == nil {
+			continue
+		} else if activeRe.MatchString(line) {
+			member.active = true
+		} else if m := mayEnableRe.FindStringSubmatch(line); m != nil {
+			member.mayEnable = m[1] == "true"
+		}
+	}
+
+	for name, bond := range bonds {
+		bridge, ok := bondBridge[name]
+		if !ok {
+			log.Debugf("bond %s not found in ovsdb", name)
+			continue
+		}
+		metric(&infoMetric, 1, bridge, name, bond.mode, bond.lacpStatus)
+		metric(&lacpNegotiatedMetric, boolValue(bond.lacpStatus == "negotiated"),
+			bridge, name)
+		for _, m := range bond.members {
+			metric(&memberEnabledMetric, boolValue(m.enabled), bridge, name, m.name)
+			metric(&memberMayEnableMetric, boolValue(m.mayEnable), bridge, name, m.name)
+			metric(&memberActiveMetric, boolValue(m.active), bridge, name, m.name)
+		}
+	}
+
+	return nil
+}
+
+func collectLacp(ctx context.Context, bondBridge map[string]string, metric metricFunc) error {
+	buf, err := appctl.OvsVSwitchd(ctx, "lacp/show")
+	if err != nil {
+		return err
+	}
+
+	bond := ""
+	bridge := ""
+	member := ""
+
+	stateFlags := func(m *lib.Metric, state string) {
+		flags := make(map[string]bool)
+		for _, f := range strings.Fields(state) {
+			flags[f] = true
+		}
+		for _, f := range lacpFlags {
+			metric(m, boolValue(flags[f]), bridge, bond, member, f)
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(buf))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := bondRe.FindStringSubmatch(line); m != nil {
+			var ok bool
+			bond = m[1]
+			bridge, ok = bondBridge[bond]
+			if !ok {
+				log.Debugf("bond %s not found in ovsdb", bond)
+				bond = ""
+			}
+			member = ""
+			continue
+		}
+		if bond == "" {
+			continue
+		}
+		if m := lacpMemberRe.FindStringSubmatch(line); m != nil {
+			member = m[1]
+			status := make(map[string]bool)
+			for _, s := range strings.Fields(m[2]) {
+				status[s] = true
+			}
+			metric(&memberLacpCurrentMetric, boolValue(status["current"]),
+				bridge, bond, member)
+			metric(&memberLacpAttachedMetric, boolValue(status["attached"]),
+				bridge, bond, member)
+		} else if member == "" {
+			continue
+		} else if m := actorStateRe.FindStringSubmatch(line); m != nil {
+			stateFlags(&memberActorStateMetric, m[1])
+		} else if m := partnerStateRe.FindStringSubmatch(line); m != nil {
+			stateFlags(&memberPartnerStateMetric, m[1])
+		}
+	}
+
+	return nil
+}
+
+func collectLacpStats(ctx context.Context, bondBridge map[string]string, metric metricFunc) error {
+	buf, err := appctl.OvsVSwitchd(ctx, "lacp/show-stats")
+	if err != nil {
+		return err
+	}
+
+	bond := ""
+	bridge := ""
+	member := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(buf))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := statsBondRe.FindStringSubmatch(line); m != nil {
+			var ok bool
+			bond = m[1]
+			bridge, ok = bondBridge[bond]
+			if !ok {
+				log.Debugf("bond %s not found in ovsdb", bond)
+				bond = ""
+			}
+			member = ""
+			continue
+		}
+		if bond == "" {
+			continue
+		}
+		if m := statsMemberRe.FindStringSubmatch(line); m != nil {
+			member = m[1]
+		} else if member == "" {
+			continue
+		} else if m := statsRe.FindStringSubmatch(line); m != nil {
+			stat, ok := lacpStats[m[1]]
+			if !ok {
+				continue
+			}
+			val, err := strconv.ParseFloat(m[2], 64)
+			if err != nil {
+				log.Errf("%s: %s: %s", m[1], m[2], err)
+				continue
+			}
+			metric(&stat, val, bridge, bond, member)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package bond
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl/appctltest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib/libtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovsdbtest"
+	"github.com/ovn-org/libovsdb/model"
+)
+
+// br-ex with an LACP bond, a bond whose members were not added and a single
+// interface port
+var bondRows = []model.Model{
+	&ovs.OpenvSwitch{UUID: "ovs", Bridges: []string{"brex"}},
+	&ovs.Bridge{UUID: "brex", Name: "br-ex", Ports: []string{"bond0", "bond1", "eth2"}},
+	&ovs.Port{UUID: "bond0", Name: "bond0", Interfaces: []string{"p0", "p1"}},
+	&ovs.Port{UUID: "bond1", Name: "bond1", Interfaces: []string{"p2", "p3"}},
+	&ovs.Port{UUID: "eth2", Name: "eth2", Interfaces: []string{"eth2if"}},
+	&ovs.Interface{UUID: "p0", Name: "p0"},
+	&ovs.Interface{UUID: "p1", Name: "p1"},
+	&ovs.Interface{UUID: "p2", Name: "p2"},
+	&ovs.Interface{UUID: "p3", Name: "p3"},
+	&ovs.Interface{UUID: "eth2if", Name: "eth2"},
+}
+
+const bondShow = `---- bond0 ----
+bond_mode: balance-tcp
+bond may use recirculation: yes, Recirc-ID : 1
+bond-hash-basis: 0
+lb_output action: disabled, bond-id: -1
+updelay: 0 ms
+downdelay: 0 ms
+next rebalance: 6817 ms
+lacp_status: negotiated
+lacp_fallback_ab: false
+active-backup primary: <none>
+active member mac: 52:54:00:e0:7e:4f(p0)
+
+member p0: enabled
+  active member
+  may_enable: true
+  hash 12: 3 kB load
+
+member p1: disabled
+  may_enable: false
+
+---- bond1 ----
+bond_mode: active-backup
+bond may use recirculation: no, Recirc-ID : -1
+bond-hash-basis: 0
+lb_output action: disabled, bond-id: -1
+updelay: 0 ms
+downdelay: 0 ms
+lacp_status: off
+lacp_fallback_ab: false
+active-backup primary: <none>
+<active member mac del>
+`
+
+const lacpShow = `---- bond0 ----
+  status: active negotiated
+  sys_id: 52:54:00:e0:7e:4f
+  sys_priority: 65534
+  aggregation key: 1
+  lacp_time: slow
+
+member: p0: current attached
+  port_id: 1
+  port_priority: 65535
+  may_enable: true
+
+  actor sys_id: 52:54:00:e0:7e:4f
+  actor sys_priority: 65534
+  actor port_id: 1
+  actor port_priority: 65535
+  actor key: 1
+  actor state: activity aggregation synchronized collecting distributing
+
+  partner sys_id: 52:54:00:11:22:33
+  partner sys_priority: 32768
+  partner port_id: 1
+  partner port_priority: 32768
+  partner key: 13
+  partner state: activity timeout aggregation synchronized collecting distributing
+
+member: p1: defaulted detached
+  port_id: 2
+  port_priority: 65535
+  may_enable: false
+
+  actor sys_id: 52:54:00:e0:7e:4f
+  actor sys_priority: 65534
+  actor port_id: 2
+  actor port_priority: 65535
+  actor key: 1
+  actor state: activity aggregation defaulted
+
+  partner sys_id: 00:00:00:00:00:00
+  partner sys_priority: 0
+  partner port_id: 0
+  partner port_priority: 0
+  partner key: 0
+  partner state:
+`
+
+const lacpShowStats = `---- bond0 statistics ----
+
+member: p0:
+  TX PDUs: 1234
+  RX PDUs: 1230
+  RX Bad PDUs: 0
+  RX Marker Request PDUs: 0
+  Link Expired: 1
+  Link Defaulted: 0
+  Carrier Status Changed: 2
+
+member: p1:
+  TX PDUs: 1234
+  RX PDUs: 0
+  RX Bad PDUs: 3
+  RX Marker Request PDUs: 0
+  Link Expired: 0
+  Link Defaulted: 1
+  Carrier Status Changed: 0
+`
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name    string
+		sets    config.MetricSet
+		rows    []model.Model
+		replies appctltest.Replies
+		want    []string
+	}{
+		{
+			name: "lacp bond",
+			sets: config.METRICS_DEFAULT,
+			rows: bondRows,
+			replies: appctltest.Replies{
+				"bond/show":       bondShow,
+				"lacp/show":       lacpShow,
+				"lacp/show-stats": lacpShowStats,
+			},
+			want: []string{
+				`ovs_bond_info{bond="bond0",bridge="br-ex",lacp_status="negotiated",mode="balance-tcp"} 1`,
+				`ovs_bond_info{bond="bond1",bridge="br-ex",lacp_status="off",mode="active-backup"} 1`,
+				`ovs_bond_lacp_negotiated{bond="bond0",bridge="br-ex"} 1`,
+				`ovs_bond_lacp_negotiated{bond="bond1",bridge="br-ex"} 0`,
+				`ovs_bond_member_active{bond="bond0",bridge="br-ex",member="p0"} 1`,
+				`ovs_bond_member_active{bond="bond0",bridge="br-ex",member="p1"} 0`,
+				`ovs_bond_member_enabled{bond="bond0",bridge="br-ex",member="p0"} 1`,
+				`ovs_bond_member_enabled{bond="bond0",bridge="br-ex",member="p1"} 0`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="activity",member="p0"} 1`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="activity",member="p1"} 1`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="aggregation",member="p0"} 1`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="aggregation",member="p1"} 1`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="collecting",member="p0"} 1`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="collecting",member="p1"} 0`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="defaulted",member="p0"} 0`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="defaulted",member="p1"} 1`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="distributing",member="p0"} 1`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="distributing",member="p1"} 0`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="expired",member="p0"} 0`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="expired",member="p1"} 0`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="synchronized",member="p0"} 1`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="synchronized",member="p1"} 0`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="timeout",member="p0"} 0`,
+				`ovs_bond_member_lacp_actor_state{bond="bond0",bridge="br-ex",flag="timeout",member="p1"} 0`,
+				`ovs_bond_member_lacp_attached{bond="bond0",bridge="br-ex",member="p0"} 1`,
+				`ovs_bond_member_lacp_attached{bond="bond0",bridge="br-ex",member="p1"} 0`,
+				`ovs_bond_member_lacp_carrier_status_changed{bond="bond0",bridge="br-ex",member="p0"} 2`,
+				`ovs_bond_member_lacp_carrier_status_changed{bond="bond0",bridge="br-ex",member="p1"} 0`,
+				`ovs_bond_member_lacp_current{bond="bond0",bridge="br-ex",member="p0"} 1`,
+				`ovs_bond_member_lacp_current{bond="bond0",bridge="br-ex",member="p1"} 0`,
+				`ovs_bond_member_lacp_link_defaulted{bond="bond0",bridge="br-ex",member="p0"} 0`,
+				`ovs_bond_member_lacp_link_defaulted{bond="bond0",bridge="br-ex",member="p1"} 1`,
+				`ovs_bond_member_lacp_link_expired{bond="bond0",bridge="br-ex",member="p0"} 1`,
+				`ovs_bond_member_lacp_link_expired{bond="bond0",bridge="br-ex",member="p1"} 0`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="activity",member="p0"} 1`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="activity",member="p1"} 0`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="aggregation",member="p0"} 1`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="aggregation",member="p1"} 0`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="collecting",member="p0"} 1`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="collecting",member="p1"} 0`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="defaulted",member="p0"} 0`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="defaulted",member="p1"} 0`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="distributing",member="p0"} 1`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="distributing",member="p1"} 0`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="expired",member="p0"} 0`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="expired",member="p1"} 0`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="synchronized",member="p0"} 1`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="synchronized",member="p1"} 0`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="timeout",member="p0"} 1`,
+				`ovs_bond_member_lacp_partner_state{bond="bond0",bridge="br-ex",flag="timeout",member="p1"} 0`,
+				`ovs_bond_member_lacp_rx_bad_pdus{bond="bond0",bridge="br-ex",member="p0"} 0`,
+				`ovs_bond_member_lacp_rx_bad_pdus{bond="bond0",bridge="br-ex",member="p1"} 3`,
+				`ovs_bond_member_lacp_rx_marker_request_pdus{bond="bond0",bridge="br-ex",member="p0"} 0`,
+				`ovs_bond_member_lacp_rx_marker_request_pdus{bond="bond0",bridge="br-ex",member="p1"} 0`,
+				`ovs_bond_member_lacp_rx_pdus{bond="bond0",bridge="br-ex",member="p0"} 1230`,
+				`ovs_bond_member_lacp_rx_pdus{bond="bond0",bridge="br-ex",member="p1"} 0`,
+				`ovs_bond_member_lacp_tx_pdus{bond="bond0",bridge="br-ex",member="p0"} 1234`,
+				`ovs_bond_member_lacp_tx_pdus{bond="bond0",bridge="br-ex",member="p1"} 1234`,
+				`ovs_bond_member_may_enable{bond="bond0",bridge="br-ex",member="p0"} 1`,
+				`ovs_bond_member_may_enable{bond="bond0",bridge="br-ex",member="p1"} 0`,
+			},
+		},
+		{
+			name: "no bonds",
+			sets: config.METRICS_DEFAULT,
+			rows: []model.Model{
+				&ovs.OpenvSwitch{UUID: "ovs", Bridges: []string{"brex"}},
+				&ovs.Bridge{UUID: "brex", Name: "br-ex", Ports: []string{"eth2"}},
+				&ovs.Port{UUID: "eth2", Name: "eth2", Interfaces: []string{"eth2if"}},
+				&ovs.Interface{UUID: "eth2if", Name: "eth2"},
+			},
+			replies: appctltest.Replies{},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := appctltest.Start(t, map[string]appctltest.Replies{
+				"ovs-vswitchd": tt.replies,
+			})
+			ctx = ovsdb.WithClient(ctx, ovsdbtest.NewClient(t, tt.rows...))
+			ctx = lib.WithMetricSets(ctx, tt.sets)
+
+			got, err := libtest.Collect(ctx, Collector{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got:\n%s\nwant:\n%s",
+					strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
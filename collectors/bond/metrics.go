@@ -0,0 +1,141 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package bond
+
+import (
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	bondLabels   = []string{"bridge", "bond"}
+	memberLabels = []string{"bridge", "bond", "member"}
+	stateLabels  = []string{"bridge", "bond", "member", "flag"}
+)
+
+var infoMetric = lib.Metric{
+	Name:        "ovs_bond_info",
+	Description: "Bond mode and LACP status. The value is always 1.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      append(bondLabels, "mode", "lacp_status"),
+	Set:         config.METRICS_BASE,
+}
+
+var lacpNegotiatedMetric = lib.Metric{
+	Name:        "ovs_bond_lacp_negotiated",
+	Description: "Has LACP negotiation succeeded on the bond.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      bondLabels,
+	Set:         config.METRICS_BASE,
+}
+
+var memberEnabledMetric = lib.Metric{
+	Name:        "ovs_bond_member_enabled",
+	Description: "Is the member enabled in the bond.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      memberLabels,
+	Set:         config.METRICS_BASE,
+}
+
+var memberMayEnableMetric = lib.Metric{
+	Name:        "ovs_bond_member_may_enable",
+	Description: "Can the member be enabled, i.e. its carrier is up and LACP (if any) agrees.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      memberLabels,
+	Set:         config.METRICS_BASE,
+}
+
+var memberActiveMetric = lib.Metric{
+	Name:        "ovs_bond_member_active",
+	Description: "Is the member the active one of an active-backup bond, or the one used for non-hashed traffic.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      memberLabels,
+	Set:         config.METRICS_BASE,
+}
+
+var memberLacpCurrentMetric = lib.Metric{
+	Name:        "ovs_bond_member_lacp_current",
+	Description: "Is the LACP information received from the partner current (not expired nor defaulted).",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      memberLabels,
+	Set:         config.METRICS_BASE,
+}
+
+var memberLacpAttachedMetric = lib.Metric{
+	Name:        "ovs_bond_member_lacp_attached",
+	Description: "Is the member attached to the LACP aggregate.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      memberLabels,
+	Set:         config.METRICS_BASE,
+}
+
+var memberActorStateMetric = lib.Metric{
+	Name:        "ovs_bond_member_lacp_actor_state",
+	Description: "Local LACP state flags of the member.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      stateLabels,
+	Set:         config.METRICS_BASE,
+}
+
+var memberPartnerStateMetric = lib.Metric{
+	Name:        "ovs_bond_member_lacp_partner_state",
+	Description: "LACP state flags advertised by the partner of the member.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      stateLabels,
+	Set:         config.METRICS_BASE,
+}
+
+// lacp/show-stats counters
+var lacpStats = map[string]lib.Metric{
+	"TX PDUs": {
+		Name:        "ovs_bond_member_lacp_tx_pdus",
+		Description: "Number of LACP PDUs sent.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      memberLabels,
+		Set:         config.METRICS_COUNTERS,
+	},
+	"RX PDUs": {
+		Name:        "ovs_bond_member_lacp_rx_pdus",
+		Description: "Number of LACP PDUs received.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      memberLabels,
+		Set:         config.METRICS_COUNTERS,
+	},
+	"RX Bad PDUs": {
+		Name:        "ovs_bond_member_lacp_rx_bad_pdus",
+		Description: "Number of invalid LACP PDUs received.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      memberLabels,
+		Set:         config.METRICS_ERRORS,
+	},
+	"RX Marker Request PDUs": {
+		Name:        "ovs_bond_member_lacp_rx_marker_request_pdus",
+		Description: "Number of LACP marker request PDUs received.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      memberLabels,
+		Set:         config.METRICS_COUNTERS,
+	},
+	"Link Expired": {
+		Name:        "ovs_bond_member_lacp_link_expired",
+		Description: "Number of times the partner information expired.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      memberLabels,
+		Set:         config.METRICS_ERRORS,
+	},
+	"Link Defaulted": {
+		Name:        "ovs_bond_member_lacp_link_defaulted",
+		Description: "Number of times the partner information was defaulted.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      memberLabels,
+		Set:         config.METRICS_ERRORS,
+	},
+	"Carrier Status Changed": {
+		Name:        "ovs_bond_member_lacp_carrier_status_changed",
+		Description: "Number of member carrier status changes.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      memberLabels,
+		Set:         config.METRICS_ERRORS,
+	},
+}
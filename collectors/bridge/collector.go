@@ -5,11 +5,10 @@ package bridge
 
 import (
 	"context"
-	"time"
+	"errors"
+	"fmt"
 
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
-	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
-	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
 	"github.com/prometheus/client_golang/prometheus"
@@ -29,29 +28,29 @@ func (Collector) Metrics() []lib.Metric {
 	return res
 }
 
-func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
-	lib.DescribeEnabledMetrics(c, ch)
-}
-
-func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+func (c *Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
 	var bridges []ovs.Bridge
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-	defer cancel()
-
+	var errs []error
 	err := ovsdb.List(ctx, &bridges)
 	if err != nil {
-		log.Errf("db.List(Bridge): %s", err)
-		return
+		return fmt.Errorf("db.List(Bridge): %w", err)
 	}
 
 	for _, br := range bridges {
 		labels := []string{br.Name, br.DatapathType}
 
 		for _, m := range metrics {
-			if config.MetricSets().Has(m.Set) {
+			if lib.MetricSets(ctx).Has(m.Set) {
+				value, err := m.GetValue(ctx, &br)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
 				ch <- prometheus.MustNewConstMetric(m.Desc(),
-					m.ValueType, m.GetValue(&br), labels...)
+					m.ValueType, value, labels...)
 			}
 		}
 	}
+
+	return errors.Join(errs...)
 }
@@ -4,35 +4,56 @@
 package collectors
 
 import (
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/acl"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/bfd"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/bond"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/bridge"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/conntrack"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/coverage"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/datapath"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/flow_table"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/iface"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lflow"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/memory"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/ovn"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/ovnnorthd"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/ovnsb"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/ovsdbserver"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/pmd_perf"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/pmd_rxq"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/pmd_stats"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/tunnel"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/upcall"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/vif"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/vswitch"
 )
 
-// All supported collectors. Please keep alpha sorted.
-var collectors = []lib.Collector{
-	new(bridge.Collector),
-	new(coverage.Collector),
-	new(datapath.Collector),
-	new(iface.Collector),
-	new(memory.Collector),
-	new(ovnnorthd.Collector),
-	new(ovn.Collector),
-	new(ovsdbserver.Collector),
-	new(pmd_perf.Collector),
-	new(pmd_rxq.Collector),
-	new(vswitch.Collector),
-}
-
+// Return new instances of all supported collectors.
 func Collectors() []lib.Collector {
-	return collectors
+	// Please keep alpha sorted.
+	return []lib.Collector{
+		new(acl.Collector),
+		new(bfd.Collector),
+		new(bond.Collector),
+		new(bridge.Collector),
+		new(conntrack.Collector),
+		new(coverage.Collector),
+		new(datapath.Collector),
+		new(flow_table.Collector),
+		new(iface.Collector),
+		new(lflow.Collector),
+		new(memory.Collector),
+		new(ovnnorthd.Collector),
+		new(ovnsb.Collector),
+		new(ovn.Collector),
+		new(ovsdbserver.Collector),
+		new(pmd_perf.Collector),
+		new(pmd_rxq.Collector),
+		new(pmd_stats.Collector),
+		new(tunnel.Collector),
+		new(upcall.Collector),
+		new(vif.Collector),
+		new(vswitch.Collector),
+	}
 }
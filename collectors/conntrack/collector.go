@@ -0,0 +1,254 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package conntrack
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Collector struct{}
+
+func (Collector) Name() string {
+	return "conntrack"
+}
+
+func (Collector) Metrics() []lib.Metric {
+	return []lib.Metric{
+		connectionsMetric, maxConnectionsMetric, fillRatioMetric,
+		protocolEntriesMetric, stateEntriesMetric,
+		zoneDefaultLimitMetric, zoneLimitMetric, zoneConnectionsMetric,
+	}
+}
+
+var (
+	// "system@ovs-system"
+	datapathRe = regexp.MustCompile(`^([\w-]+)@([\w-]+)$`)
+	// "    Total: 1042" or "    TCP: 1000"
+	countRe = regexp.MustCompile(`^ {4}(\w+): (\d+)$`)
+	// "	Conn per TCP states:"
+	statesRe = regexp.MustCompile(`^\s+Conn per (\w+) states:$`)
+	// "	  [ESTABLISHED]=982"
+	stateRe = regexp.MustCompile(`^\s+\[(\w+)\]=(\d+)$`)
+	// "default limit=0"
+	defaultLimitRe = regexp.MustCompile(`^default limit=(\d+)$`)
+	// "zone=12,limit=1000,count=31"
+	zoneLimitRe = regexp.MustCompile(`^zone=(\d+),limit=(\d+),count=(\d+)$`)
+	// "a4c1e2f0-6b3d-4c1e-9d2a-0e5f1b7c8d90 12"
+	ctZoneRe = regexp.MustCompile(`^(\S+)\s+(\d+)$`)
+)
+
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	sets := lib.MetricSets(ctx)
+	if !sets.Has(config.METRICS_BASE) && !sets.Has(config.METRICS_PERF) {
+		return nil
+	}
+
+	buf, err := appctl.OvsVSwitchd(ctx, "dpctl/dump-dps")
+	if err != nil {
+		return err
+	}
+
+	var ports map[string]string
+	if sets.Has(config.METRICS_PERF) {
+		ports, err = getZonePorts(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(buf))
+	for scanner.Scan() {
+		match := datapathRe.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		if err := collectDatapath(ctx, ch, match[0], match[1], match[2]); err != nil {
+			return err
+		}
+		if ports == nil {
+			continue
+		}
+		if err := collectZones(ctx, ch, ports, match[0], match[1], match[2]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func collectDatapath(
+	ctx context.Context, ch chan<- prometheus.Metric, dp, dptype, dpname string,
+) error {
+	sets := lib.MetricSets(ctx)
+
+	buf, err := appctl.OvsVSwitchd(ctx, "dpctl/ct-stats-show", "-m", dp)
+	if err != nil {
+		return err
+	}
+
+	total := -1.0
+	// protocol of the "Conn per <protocol> states:" block being parsed
+	protocol := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(buf))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := countRe.FindStringSubmatch(line); m != nil {
+			val, _ := strconv.ParseFloat(m[2], 64)
+			protocol = ""
+			if m[1] == "Total" {
+				total = val
+			} else if sets.Has(protocolEntriesMetric.Set) {
+				ch <- prometheus.MustNewConstMetric(
+					protocolEntriesMetric.Desc(), protocolEntriesMetric.ValueType,
+					val, dptype, dpname, m[1])
+			}
+		} else if m := statesRe.FindStringSubmatch(line); m != nil {
+			protocol = m[1]
+		} else if m := stateRe.FindStringSubmatch(line); m != nil && protocol != "" {
+			if sets.Has(stateEntriesMetric.Set) {
+				val, _ := strconv.ParseFloat(m[2], 64)
+				ch <- prometheus.MustNewConstMetric(
+					stateEntriesMetric.Desc(), stateEntriesMetric.ValueType,
+					val, dptype, dpname, protocol, m[1])
+			}
+		}
+	}
+
+	if !sets.Has(config.METRICS_BASE) {
+		return nil
+	}
+
+	// ct-get-nconns and ct-get-maxconns are only supported by the
+	// userspace datapath, use the ct-stats-show total otherwise
+	nconns, err := getConns(ctx, "dpctl/ct-get-nconns", dp)
+	if err != nil {
+		return err
+	}
+	if nconns < 0 {
+		nconns = total
+	}
+	if nconns >= 0 {
+		ch <- prometheus.MustNewConstMetric(
+			connectionsMetric.Desc(), connectionsMetric.ValueType,
+			nconns, dptype, dpname)
+	}
+
+	maxconns, err := getConns(ctx, "dpctl/ct-get-maxconns", dp)
+	if err != nil {
+		return err
+	}
+	if maxconns >= 0 {
+		ch <- prometheus.MustNewConstMetric(
+			maxConnectionsMetric.Desc(), maxConnectionsMetric.ValueType,
+			maxconns, dptype, dpname)
+	}
+	if maxconns > 0 && nconns >= 0 {
+		ch <- prometheus.MustNewConstMetric(
+			fillRatioMetric.Desc(), fillRatioMetric.ValueType,
+			nconns/maxconns, dptype, dpname)
+	}
+
+	return nil
+}
+
+// Return the number printed by a ct-get-*conns command or -1 if the
+// datapath does not support it.
+func getConns(ctx context.Context, method, dp string) (float64, error) {
+	buf, err := appctl.OvsVSwitchd(ctx, method, dp)
+	var rpcErr *appctl.RPCError
+	if errors.As(err, &rpcErr) {
+		log.Debugf("%s", err)
+		return -1, nil
+	} else if err != nil {
+		return -1, err
+	}
+	val, err := strconv.ParseFloat(strings.TrimSpace(buf), 64)
+	if err != nil {
+		log.Errf("%s: %q: %s", method, buf, err)
+		return -1, nil
+	}
+	return val, nil
+}
+
+// Return the logical port names indexed by conntrack zone id, as allocated by
+// ovn-controller. Router zones ("<uuid>_dnat" and "<uuid>_snat") are not
+// included. Return an empty map if ovn-controller is not running.
+func getZonePorts(ctx context.Context) (map[string]string, error) {
+	ports := make(map[string]string)
+
+	buf, err := appctl.OvnController(ctx, "ct-zone-list")
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		log.Debugf("%s", err)
+		return ports, nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(buf))
+	for scanner.Scan() {
+		m := ctZoneRe.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		if strings.HasSuffix(m[1], "_dnat") || strings.HasSuffix(m[1], "_snat") {
+			continue
+		}
+		ports[m[2]] = m[1]
+	}
+
+	return ports, nil
+}
+
+func collectZones(
+	ctx context.Context, ch chan<- prometheus.Metric,
+	ports map[string]string, dp, dptype, dpname string,
+) error {
+	buf, err := appctl.OvsVSwitchd(ctx, "dpctl/ct-get-limits", dp)
+	var rpcErr *appctl.RPCError
+	if errors.As(err, &rpcErr) {
+		// conntrack zone limits not supported by the datapath
+		log.Debugf("%s", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(buf))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if m := defaultLimitRe.FindStringSubmatch(line); m != nil {
+			val, _ := strconv.ParseFloat(m[1], 64)
+			ch <- prometheus.MustNewConstMetric(
+				zoneDefaultLimitMetric.Desc(), zoneDefaultLimitMetric.ValueType,
+				val, dptype, dpname)
+		} else if m := zoneLimitRe.FindStringSubmatch(line); m != nil {
+			zone, port := m[1], ports[m[1]]
+			val, _ := strconv.ParseFloat(m[2], 64)
+			ch <- prometheus.MustNewConstMetric(
+				zoneLimitMetric.Desc(), zoneLimitMetric.ValueType,
+				val, dptype, dpname, zone, port)
+			val, _ = strconv.ParseFloat(m[3], 64)
+			ch <- prometheus.MustNewConstMetric(
+				zoneConnectionsMetric.Desc(), zoneConnectionsMetric.ValueType,
+				val, dptype, dpname, zone, port)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package conntrack
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl/appctltest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib/libtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+)
+
+const kernelStats = `Connections Stats:
+    Total: 1042
+    TCP: 1000
+	Conn per TCP states:
+	  [SYN_SENT]=2
+	  [ESTABLISHED]=980
+	  [TIME_WAIT]=18
+    UDP: 38
+    ICMPV6: 3
+    OTHER: 1
+`
+
+const netdevStats = `Connections Stats:
+    Total: 12
+    UDP: 12
+`
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name    string
+		sets    config.MetricSet
+		replies appctltest.Replies
+		ovn     appctltest.Replies
+		want    []string
+	}{
+		{
+			name: "kernel datapath",
+			sets: config.METRICS_DEFAULT,
+			replies: appctltest.Replies{
+				"dpctl/dump-dps": "system@ovs-system\n",
+				"dpctl/ct-stats-show -m system@ovs-system": kernelStats,
+			},
+			want: []string{
+				`ovs_conntrack_connections{name="ovs-system",type="system"} 1042`,
+				`ovs_conntrack_protocol_entries{name="ovs-system",protocol="ICMPV6",type="system"} 3`,
+				`ovs_conntrack_protocol_entries{name="ovs-system",protocol="OTHER",type="system"} 1`,
+				`ovs_conntrack_protocol_entries{name="ovs-system",protocol="TCP",type="system"} 1000`,
+				`ovs_conntrack_protocol_entries{name="ovs-system",protocol="UDP",type="system"} 38`,
+				`ovs_conntrack_state_entries{name="ovs-system",protocol="TCP",state="ESTABLISHED",type="system"} 980`,
+				`ovs_conntrack_state_entries{name="ovs-system",protocol="TCP",state="SYN_SENT",type="system"} 2`,
+				`ovs_conntrack_state_entries{name="ovs-system",protocol="TCP",state="TIME_WAIT",type="system"} 18`,
+			},
+		},
+		{
+			name: "userspace datapath",
+			sets: config.METRICS_DEFAULT,
+			replies: appctltest.Replies{
+				"dpctl/dump-dps": "netdev@ovs-netdev\n",
+				"dpctl/ct-stats-show -m netdev@ovs-netdev": netdevStats,
+				"dpctl/ct-get-nconns netdev@ovs-netdev":    "14\n",
+				"dpctl/ct-get-maxconns netdev@ovs-netdev":  "56\n",
+			},
+			want: []string{
+				`ovs_conntrack_connections{name="ovs-netdev",type="netdev"} 14`,
+				`ovs_conntrack_fill_ratio{name="ovs-netdev",type="netdev"} 0.25`,
+				`ovs_conntrack_max_connections{name="ovs-netdev",type="netdev"} 56`,
+				`ovs_conntrack_protocol_entries{name="ovs-netdev",protocol="UDP",type="netdev"} 12`,
+			},
+		},
+		{
+			name: "no connections",
+			sets: config.METRICS_DEFAULT,
+			replies: appctltest.Replies{
+				"dpctl/dump-dps": "system@ovs-system\n",
+				"dpctl/ct-stats-show -m system@ovs-system": "Connections Stats:\n    Total: 0\n",
+			},
+			want: []string{
+				`ovs_conntrack_connections{name="ovs-system",type="system"} 0`,
+			},
+		},
+		{
+			name: "base set only",
+			sets: config.METRICS_BASE,
+			replies: appctltest.Replies{
+				"dpctl/dump-dps": "system@ovs-system\n",
+				"dpctl/ct-stats-show -m system@ovs-system": kernelStats,
+			},
+			want: []string{
+				`ovs_conntrack_connections{name="ovs-system",type="system"} 1042`,
+			},
+		},
+		{
+			name: "zone limits",
+			sets: config.METRICS_DEFAULT,
+			replies: appctltest.Replies{
+				"dpctl/dump-dps": "system@ovs-system\n",
+				"dpctl/ct-stats-show -m system@ovs-system": netdevStats,
+				"dpctl/ct-get-limits system@ovs-system": "default limit=0\n" +
+					"zone=5,limit=1000,count=12\n" +
+					"zone=7,limit=500,count=0\n",
+			},
+			ovn: appctltest.Replies{
+				"ct-zone-list": "a4c1e2f0-6b3d-4c1e-9d2a-0e5f1b7c8d90_dnat 7\n" +
+					"a4c1e2f0-6b3d-4c1e-9d2a-0e5f1b7c8d90_snat 6\n" +
+					"vm1-port 5\n",
+			},
+			want: []string{
+				`ovs_conntrack_connections{name="ovs-system",type="system"} 12`,
+				`ovs_conntrack_protocol_entries{name="ovs-system",protocol="UDP",type="system"} 12`,
+				`ovs_conntrack_zone_connections{logical_port="",name="ovs-system",type="system",zone="7"} 0`,
+				`ovs_conntrack_zone_connections{logical_port="vm1-port",name="ovs-system",type="system",zone="5"} 12`,
+				`ovs_conntrack_zone_default_limit{name="ovs-system",type="system"} 0`,
+				`ovs_conntrack_zone_limit{logical_port="",name="ovs-system",type="system",zone="7"} 500`,
+				`ovs_conntrack_zone_limit{logical_port="vm1-port",name="ovs-system",type="system",zone="5"} 1000`,
+			},
+		},
+		{
+			name: "no datapath",
+			sets: config.METRICS_DEFAULT,
+			replies: appctltest.Replies{
+				"dpctl/dump-dps": "",
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			daemons := map[string]appctltest.Replies{
+				"ovs-vswitchd": tt.replies,
+			}
+			if tt.ovn != nil {
+				daemons["ovn-controller"] = tt.ovn
+			}
+			ctx := appctltest.Start(t, daemons)
+			ctx = lib.WithMetricSets(ctx, tt.sets)
+
+			got, err := libtest.Collect(ctx, Collector{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got:\n%s\nwant:\n%s",
+					strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package conntrack
+
+import (
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var commonLabels = []string{"type", "name"}
+
+var connectionsMetric = lib.Metric{
+	Name:        "ovs_conntrack_connections",
+	Description: "The number of connections tracked by the datapath.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      commonLabels,
+	Set:         config.METRICS_BASE,
+}
+
+var maxConnectionsMetric = lib.Metric{
+	Name:        "ovs_conntrack_max_connections",
+	Description: "The maximum number of connections that can be tracked by the datapath (userspace datapath only).",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      commonLabels,
+	Set:         config.METRICS_BASE,
+}
+
+var fillRatioMetric = lib.Metric{
+	Name:        "ovs_conntrack_fill_ratio",
+	Description: "The number of tracked connections divided by the maximum (userspace datapath only).",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      commonLabels,
+	Set:         config.METRICS_BASE,
+}
+
+var protocolEntriesMetric = lib.Metric{
+	Name:        "ovs_conntrack_protocol_entries",
+	Description: "The number of conntrack entries by protocol.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      append(commonLabels, "protocol"),
+	Set:         config.METRICS_PERF,
+}
+
+var stateEntriesMetric = lib.Metric{
+	Name:        "ovs_conntrack_state_entries",
+	Description: "The number of conntrack entries by protocol and connection state.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      append(commonLabels, "protocol", "state"),
+	Set:         config.METRICS_PERF,
+}
+
+var zoneLabels = append(commonLabels, "zone", "logical_port")
+
+var zoneDefaultLimitMetric = lib.Metric{
+	Name:        "ovs_conntrack_zone_default_limit",
+	Description: "The maximum number of connections in zones without a specific limit (0 means unlimited).",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      commonLabels,
+	Set:         config.METRICS_PERF,
+}
+
+var zoneLimitMetric = lib.Metric{
+	Name:        "ovs_conntrack_zone_limit",
+	Description: "The maximum number of connections in a conntrack zone (0 means unlimited).",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      zoneLabels,
+	Set:         config.METRICS_PERF,
+}
+
+var zoneConnectionsMetric = lib.Metric{
+	Name:        "ovs_conntrack_zone_connections",
+	Description: "The number of connections tracked in a conntrack zone.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      zoneLabels,
+	Set:         config.METRICS_PERF,
+}
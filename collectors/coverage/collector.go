@@ -5,23 +5,23 @@ package coverage
 
 import (
 	"bufio"
+	"context"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
-	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-func makeMetric(name, value string) prometheus.Metric {
+func makeMetric(ctx context.Context, name, value string) prometheus.Metric {
 	m, ok := metrics[name]
 	if !ok {
 		return nil
 	}
-	if !config.MetricSets().Has(m.Set) {
+	if !lib.MetricSets(ctx).Has(m.Set) {
 		return nil
 	}
 
@@ -48,17 +48,13 @@ func (Collector) Metrics() []lib.Metric {
 	return res
 }
 
-func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
-	lib.DescribeEnabledMetrics(c, ch)
-}
-
 // "netdev_sent       967178.4/sec 966510.667/sec   880482.1181/sec   total: 21235468562413"
 var coverageRe = regexp.MustCompile(`^(\w+)\s+.*\s+total: (\d+)$`)
 
-func (Collector) Collect(ch chan<- prometheus.Metric) {
-	buf := appctl.OvsVSwitchd("coverage/show")
-	if buf == "" {
-		return
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	buf, err := appctl.OvsVSwitchd(ctx, "coverage/show")
+	if err != nil {
+		return err
 	}
 
 	scanner := bufio.NewScanner(strings.NewReader(buf))
@@ -67,10 +63,12 @@ func (Collector) Collect(ch chan<- prometheus.Metric) {
 
 		match := coverageRe.FindStringSubmatch(line)
 		if match != nil {
-			metric := makeMetric(match[1], match[2])
+			metric := makeMetric(ctx, match[1], match[2])
 			if metric != nil {
 				ch <- metric
 			}
 		}
 	}
+
+	return nil
 }
@@ -5,6 +5,7 @@ package datapath
 
 import (
 	"bufio"
+	"context"
 	"regexp"
 	"strconv"
 	"strings"
@@ -25,10 +26,6 @@ func (Collector) Metrics() []lib.Metric {
 	return []lib.Metric{flowsMetric, hitsMetric, missedMetric, lostMetric}
 }
 
-func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
-	lib.DescribeEnabledMetrics(c, ch)
-}
-
 var (
 	// "netdev@ovs-netdev:"
 	datapathRe = regexp.MustCompile(`^([\w-]+)@([\w-]+):$`)
@@ -38,14 +35,14 @@ var (
 	flowsRe = regexp.MustCompile(`^  flows:\s*(\d+)$`)
 )
 
-func (Collector) Collect(ch chan<- prometheus.Metric) {
-	if !config.MetricSets().Has(config.METRICS_PERF) {
-		return
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if !lib.MetricSets(ctx).Has(config.METRICS_PERF) {
+		return nil
 	}
 
-	buf := appctl.OvsVSwitchd("dpctl/show")
-	if buf == "" {
-		return
+	buf, err := appctl.OvsVSwitchd(ctx, "dpctl/show")
+	if err != nil {
+		return err
 	}
 
 	dptype := ""
@@ -85,4 +82,6 @@ func (Collector) Collect(ch chan<- prometheus.Metric) {
 			dpname = match[2]
 		}
 	}
+
+	return nil
 }
@@ -0,0 +1,79 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package collectors
+
+import (
+	"fmt"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+)
+
+// Filter restricts a scrape to a subset of the enabled collectors and
+// metric sets.
+type Filter struct {
+	// Names of the collectors to run. Empty means all enabled collectors.
+	Collectors []string
+	// Metric sets to gather.
+	MetricSets config.MetricSet
+}
+
+// Parse and validate the collector and metric set names requested by
+// a scraper. Collectors must be known and enabled, metric sets must be valid
+// and part of the enabled sets. When no metric set is specified, all enabled
+// metric sets are gathered.
+func ParseFilter(
+	all []lib.Collector, enabled []lib.Collector, enabledSets config.MetricSet,
+	names []string, sets []string,
+) (Filter, error) {
+	var f Filter
+
+	for _, name := range names {
+		if FindCollector(all, name) == nil {
+			return f, fmt.Errorf("unknown collector: %q", name)
+		}
+		if FindCollector(enabled, name) == nil {
+			return f, fmt.Errorf("collector not enabled: %q", name)
+		}
+		f.Collectors = append(f.Collectors, name)
+	}
+
+	if len(sets) == 0 {
+		f.MetricSets = enabledSets
+	} else {
+		s, err := config.ParseMetricSets(sets)
+		if err != nil {
+			return f, err
+		}
+		if missing := s &^ enabledSets; missing != config.METRICS_NONE {
+			return f, fmt.Errorf("metric set not enabled: %s", missing)
+		}
+		f.MetricSets = s
+	}
+
+	return f, nil
+}
+
+// Return the collector with the given name, or nil if not found.
+func FindCollector(list []lib.Collector, name string) lib.Collector {
+	for _, c := range list {
+		if c.Name() == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Return true if the collector with the given name must be run.
+func (f Filter) selects(name string) bool {
+	if len(f.Collectors) == 0 {
+		return true
+	}
+	for _, n := range f.Collectors {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package flow_table
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Collector struct{}
+
+func (Collector) Name() string {
+	return "flow-table"
+}
+
+func (Collector) Metrics() []lib.Metric {
+	return []lib.Metric{activeFlowsMetric, lookupsMetric, matchesMetric}
+}
+
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if !lib.MetricSets(ctx).Has(config.METRICS_PERF) {
+		return nil
+	}
+
+	var bridges []ovs.Bridge
+	var errs []error
+
+	target := lib.TargetFrom(ctx)
+	if target == nil {
+		return errors.New("no target in context")
+	}
+
+	err := ovsdb.List(ctx, &bridges)
+	if err != nil {
+		return fmt.Errorf("db.List(Bridge): %w", err)
+	}
+
+	for _, br := range bridges {
+		tables, err := openflow.GetTableStats(ctx, br.Name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: GetTableStats: %w", br.Name, err))
+			continue
+		}
+		for _, t := range tables {
+			// OVS reports all 254 tables, skip the ones never used
+			if t.ActiveCount == 0 && t.LookupCount == 0 {
+				continue
+			}
+			stage := ""
+			if br.Name == target.IntBridge() {
+				stage = target.OvnTables.Stage(t.TableId)
+			}
+			labels := []string{br.Name, strconv.Itoa(int(t.TableId)), stage}
+
+			ch <- prometheus.MustNewConstMetric(
+				activeFlowsMetric.Desc(), activeFlowsMetric.ValueType,
+				float64(t.ActiveCount), labels...)
+			ch <- prometheus.MustNewConstMetric(
+				lookupsMetric.Desc(), lookupsMetric.ValueType,
+				float64(t.LookupCount), labels...)
+			ch <- prometheus.MustNewConstMetric(
+				matchesMetric.Desc(), matchesMetric.ValueType,
+				float64(t.MatchedCount), labels...)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package flow_table
+
+import (
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var labels = []string{"bridge", "table", "stage"}
+
+var activeFlowsMetric = lib.Metric{
+	Name:        "ovs_flow_table_active_flows",
+	Description: "The number of openflow rules installed in a bridge table.",
+	Labels:      labels,
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_PERF,
+}
+
+var lookupsMetric = lib.Metric{
+	Name:        "ovs_flow_table_lookups",
+	Description: "The number of packets looked up in a bridge table.",
+	Labels:      labels,
+	ValueType:   prometheus.CounterValue,
+	Set:         config.METRICS_PERF,
+}
+
+var matchesMetric = lib.Metric{
+	Name:        "ovs_flow_table_matches",
+	Description: "The number of packets that matched an openflow rule of a bridge table.",
+	Labels:      labels,
+	ValueType:   prometheus.CounterValue,
+	Set:         config.METRICS_PERF,
+}
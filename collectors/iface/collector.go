@@ -5,12 +5,10 @@ package iface
 
 import (
 	"context"
+	"fmt"
 	"strconv"
-	"time"
 
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
-	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
-	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
 	"github.com/prometheus/client_golang/prometheus"
@@ -30,32 +28,22 @@ func (Collector) Metrics() []lib.Metric {
 	return res
 }
 
-func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
-	lib.DescribeEnabledMetrics(c, ch)
-}
-
-func (Collector) Collect(ch chan<- prometheus.Metric) {
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
 	var bridges []ovs.Bridge
 	var ports []ovs.Port
 	var ifaces []ovs.Interface
 
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-	defer cancel()
-
 	err := ovsdb.List(ctx, &bridges)
 	if err != nil {
-		log.Errf("db.List(Bridge): %s", err)
-		return
+		return fmt.Errorf("db.List(Bridge): %w", err)
 	}
 	err = ovsdb.List(ctx, &ports)
 	if err != nil {
-		log.Errf("db.List(Port): %s", err)
-		return
+		return fmt.Errorf("db.List(Port): %w", err)
 	}
 	err = ovsdb.List(ctx, &ifaces)
 	if err != nil {
-		log.Errf("db.List(Interface): %s", err)
-		return
+		return fmt.Errorf("db.List(Interface): %w", err)
 	}
 
 	portBridge := make(map[string]string)
@@ -88,7 +76,7 @@ func (Collector) Collect(ch chan<- prometheus.Metric) {
 		labels := []string{bridge, port, i.Name, i.Type}
 
 		for _, m := range metrics {
-			if config.MetricSets().Has(m.Set) {
+			if lib.MetricSets(ctx).Has(m.Set) {
 				if m.GetValueLabel != nil {
 					for index := 0; ; index++ {
 						if value, ok := m.GetValueLabel(&i, index); ok {
@@ -105,4 +93,6 @@ func (Collector) Collect(ch chan<- prometheus.Metric) {
 			}
 		}
 	}
+
+	return nil
 }
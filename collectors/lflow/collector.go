@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package lflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/skydive-project/goloxi/of10"
+)
+
+type Collector struct{}
+
+func (Collector) Name() string {
+	return "lflow"
+}
+
+func (Collector) Metrics() []lib.Metric {
+	return []lib.Metric{packetsMetric, bytesMetric, rulesMetric}
+}
+
+// ovn-controller sets the cookie of every openflow rule to the first 32 bits
+// of the logical flow UUID. Rules that do not come from a logical flow have
+// a zero cookie.
+type lflowKey struct {
+	cookie uint32
+	table  uint8
+}
+
+type lflowStats struct {
+	lflowKey
+	packets uint64
+	bytes   uint64
+	rules   uint64
+}
+
+// Logical flows reported during the previous scrape of a target.
+type selection struct {
+	lock sync.Mutex
+	keys map[lflowKey]bool
+}
+
+// Return the n busiest logical flows of sorted along with the previously
+// reported ones that are still among the 2*n busiest. This avoids series
+// coming and going when flows with similar traffic swap ranks. Only the
+// returned flows are remembered, the ones missing from sorted are dropped.
+func (s *selection) update(sorted []*lflowStats, n int) []*lflowStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var selected []*lflowStats
+	keys := make(map[lflowKey]bool)
+	for i, f := range sorted {
+		if i < n || (i < 2*n && s.keys[f.lflowKey]) {
+			selected = append(selected, f)
+			keys[f.lflowKey] = true
+		}
+	}
+	s.keys = keys
+
+	return selected
+}
+
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if !lib.MetricSets(ctx).Has(config.METRICS_DEBUG) {
+		return nil
+	}
+
+	t := lib.TargetFrom(ctx)
+	if t == nil {
+		return errors.New("no target in context")
+	}
+	intBridge := t.IntBridge()
+
+	tables := t.LflowTables
+	if len(tables) == 0 {
+		tables = t.OvnTables.LogicalPipelines()
+	}
+
+	lflows := make(map[lflowKey]*lflowStats)
+	for _, table := range tables {
+		err := openflow.WalkFlowStats(ctx, intBridge, table, func(f *of10.NiciraFlowStats) error {
+			key := lflowKey{cookie: uint32(f.GetCookie()), table: f.GetTableId()}
+			if key.cookie == 0 {
+				return nil
+			}
+			s, ok := lflows[key]
+			if !ok {
+				s = &lflowStats{lflowKey: key}
+				lflows[key] = s
+			}
+			s.packets += f.GetPacketCount()
+			s.bytes += f.GetByteCount()
+			s.rules++
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("%s: WalkFlowStats: %w", intBridge, err)
+		}
+	}
+
+	top := make([]*lflowStats, 0, len(lflows))
+	for _, s := range lflows {
+		top = append(top, s)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].packets != top[j].packets {
+			return top[i].packets > top[j].packets
+		}
+		return top[i].bytes > top[j].bytes
+	})
+	sel := t.CollectorState("lflow", func() any { return new(selection) })
+	top = sel.(*selection).update(top, t.LflowTopN)
+
+	for _, s := range top {
+		labels := []string{
+			fmt.Sprintf("%08x", s.cookie),
+			strconv.Itoa(int(s.table)),
+			t.OvnTables.Stage(s.table),
+		}
+		ch <- prometheus.MustNewConstMetric(
+			packetsMetric.Desc(), packetsMetric.ValueType,
+			float64(s.packets), labels...)
+		ch <- prometheus.MustNewConstMetric(
+			bytesMetric.Desc(), bytesMetric.ValueType,
+			float64(s.bytes), labels...)
+		ch <- prometheus.MustNewConstMetric(
+			rulesMetric.Desc(), rulesMetric.ValueType,
+			float64(s.rules), labels...)
+	}
+
+	return nil
+}
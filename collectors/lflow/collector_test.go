@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package lflow
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSelection(t *testing.T) {
+	// flows sorted by decreasing number of packets
+	dump := func(cookies ...uint32) []*lflowStats {
+		var flows []*lflowStats
+		for i, c := range cookies {
+			flows = append(flows, &lflowStats{
+				lflowKey: lflowKey{cookie: c, table: 8},
+				packets:  uint64(1000 - i),
+			})
+		}
+		return flows
+	}
+	cookies := func(flows []*lflowStats) []uint32 {
+		var res []uint32
+		for _, f := range flows {
+			res = append(res, f.cookie)
+		}
+		return res
+	}
+
+	scrapes := []struct {
+		name string
+		dump []*lflowStats
+		want []uint32
+	}{
+		{
+			name: "first scrape",
+			dump: dump(1, 2, 3, 4, 5),
+			want: []uint32{1, 2},
+		},
+		{
+			name: "previous flows within 2*n",
+			dump: dump(3, 4, 1, 2, 5),
+			want: []uint32{3, 4, 1, 2},
+		},
+		{
+			name: "previous flows beyond 2*n",
+			dump: dump(5, 6, 7, 8, 1, 2, 3, 4),
+			want: []uint32{5, 6},
+		},
+		{
+			name: "flows missing from the dump",
+			dump: dump(9, 10, 11, 12),
+			want: []uint32{9, 10},
+		},
+		{
+			name: "flows back in the dump",
+			dump: dump(1, 2, 5, 6),
+			want: []uint32{1, 2},
+		},
+		{
+			name: "empty dump",
+			dump: nil,
+			want: nil,
+		},
+	}
+
+	var sel selection
+
+	for _, s := range scrapes {
+		got := cookies(sel.update(s.dump, 2))
+		if !slices.Equal(got, s.want) {
+			t.Fatalf("%s: got %v, want %v", s.name, got, s.want)
+		}
+		if len(sel.keys) != len(s.want) {
+			t.Fatalf("%s: %d flows remembered, want %d",
+				s.name, len(sel.keys), len(s.want))
+		}
+	}
+}
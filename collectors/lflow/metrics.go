@@ -0,0 +1,36 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package lflow
+
+import (
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var labels = []string{"cookie", "table", "stage"}
+
+var packetsMetric = lib.Metric{
+	Name:        "ovnc_lflow_packets",
+	Description: "The number of packets that matched the openflow rules of a logical flow on the integration bridge.",
+	Labels:      labels,
+	ValueType:   prometheus.CounterValue,
+	Set:         config.METRICS_DEBUG,
+}
+
+var bytesMetric = lib.Metric{
+	Name:        "ovnc_lflow_bytes",
+	Description: "The number of bytes that matched the openflow rules of a logical flow on the integration bridge.",
+	Labels:      labels,
+	ValueType:   prometheus.CounterValue,
+	Set:         config.METRICS_DEBUG,
+}
+
+var rulesMetric = lib.Metric{
+	Name:        "ovnc_lflow_openflow_rules",
+	Description: "The number of openflow rules installed on the integration bridge for a logical flow.",
+	Labels:      labels,
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_DEBUG,
+}
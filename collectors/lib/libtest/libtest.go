@@ -0,0 +1,94 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+// Package libtest provides helpers to test collectors.
+package libtest
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Wraps a collector so that it can be gathered by a prometheus.Registry.
+type unchecked struct {
+	ctx       context.Context
+	collector lib.Collector
+	err       error
+}
+
+func (u *unchecked) Describe(ch chan<- *prometheus.Desc) {}
+
+func (u *unchecked) Collect(ch chan<- prometheus.Metric) {
+	u.err = u.collector.Collect(u.ctx, ch)
+}
+
+// Run a collector and return the samples it sent, one per line, in the
+// prometheus text format without comments:
+//
+//	name{label="value",...} value
+//
+// Histograms are expanded to their _bucket, _sum and _count samples. Lines
+// are sorted. The error returned by the collector, if any, is returned along
+// with the samples gathered before it failed.
+func Collect(ctx context.Context, c lib.Collector) ([]string, error) {
+	u := &unchecked{ctx: ctx, collector: c}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(u); err != nil {
+		return nil, err
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+
+	for _, f := range families {
+		for _, m := range f.GetMetric() {
+			var labels []string
+			for _, l := range m.GetLabel() {
+				labels = append(labels, fmt.Sprintf("%s=%q", l.GetName(), l.GetValue()))
+			}
+			sample := func(suffix string, value float64, extra ...string) {
+				l := append(append([]string(nil), labels...), extra...)
+				line := f.GetName() + suffix
+				if len(l) > 0 {
+					line += "{" + strings.Join(l, ",") + "}"
+				}
+				lines = append(lines, line+" "+strconv.FormatFloat(value, 'g', -1, 64))
+			}
+			switch {
+			case m.GetGauge() != nil:
+				sample("", m.GetGauge().GetValue())
+			case m.GetCounter() != nil:
+				sample("", m.GetCounter().GetValue())
+			case m.GetUntyped() != nil:
+				sample("", m.GetUntyped().GetValue())
+			case m.GetHistogram() != nil:
+				h := m.GetHistogram()
+				for _, b := range h.GetBucket() {
+					if math.IsInf(b.GetUpperBound(), +1) {
+						continue
+					}
+					le := strconv.FormatFloat(b.GetUpperBound(), 'g', -1, 64)
+					sample("_bucket", float64(b.GetCumulativeCount()), fmt.Sprintf("le=%q", le))
+				}
+				sample("_bucket", float64(h.GetSampleCount()), `le="+Inf"`)
+				sample("_sum", h.GetSampleSum())
+				sample("_count", float64(h.GetSampleCount()))
+			}
+		}
+	}
+
+	sort.Strings(lines)
+
+	return lines, u.err
+}
@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -11,11 +12,18 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// A Collector is similar to a prometheus.Collector but it reports failures to
+// gather its metrics instead of silently emitting nothing. Collectors are not
+// registered directly into a prometheus.Registry. They are wrapped so that
+// scrape success and duration can be exported for each one of them.
 type Collector interface {
-	prometheus.Collector
-
 	Name() string
 	Metrics() []Metric
+	// Send all enabled metrics to ch. Return a non-nil error if some or
+	// all metrics could not be gathered. The context deadline is derived
+	// from the scrape timeout and must be passed to all appctl, ovsdb and
+	// openflow calls.
+	Collect(ctx context.Context, ch chan<- prometheus.Metric) error
 }
 
 type Metric struct {
@@ -23,8 +31,18 @@ type Metric struct {
 	Description string
 	Labels      []string
 	ValueType   prometheus.ValueType
-	Set         config.MetricSet
-	desc        *prometheus.Desc
+	// Exported as a histogram, ValueType is ignored.
+	Histogram bool
+	Set       config.MetricSet
+	desc      *prometheus.Desc
+}
+
+// Return the metric type as displayed in the metrics list.
+func (m *Metric) TypeName() string {
+	if m.Histogram {
+		return "histogram"
+	}
+	return strings.ToLower(m.ValueType.ToDTO().String())
 }
 
 func (m *Metric) Desc() *prometheus.Desc {
@@ -34,9 +52,28 @@ func (m *Metric) Desc() *prometheus.Desc {
 	return m.desc
 }
 
-func DescribeEnabledMetrics(c Collector, ch chan<- *prometheus.Desc) {
+type metricSetsKey struct{}
+
+// Return a copy of ctx that restricts the metrics gathered by collectors to
+// the specified sets.
+func WithMetricSets(ctx context.Context, sets config.MetricSet) context.Context {
+	return context.WithValue(ctx, metricSetsKey{}, sets)
+}
+
+// Return the metric sets that must be gathered during the current scrape.
+// Default to config.METRICS_DEFAULT.
+func MetricSets(ctx context.Context) config.MetricSet {
+	if sets, ok := ctx.Value(metricSetsKey{}).(config.MetricSet); ok {
+		return sets
+	}
+	return config.METRICS_DEFAULT
+}
+
+// Send the descriptions of all metrics of a collector that belong to the
+// specified sets.
+func DescribeMetrics(c Collector, sets config.MetricSet, ch chan<- *prometheus.Desc) {
 	for _, m := range c.Metrics() {
-		if config.MetricSets().Has(m.Set) {
+		if sets.Has(m.Set) {
 			log.Debugf("%T: enabling metric %s", c, m.Name)
 			ch <- m.Desc()
 		}
@@ -83,7 +120,7 @@ func PrintMetrics(collectors []Collector, format string) {
 				m.Name,
 				c.Name(),
 				m.Set.String(),
-				strings.ToLower(m.ValueType.ToDTO().String()),
+				m.TypeName(),
 				strings.Join(m.Labels, ","),
 				m.Description,
 			}
@@ -95,7 +132,7 @@ func PrintMetrics(collectors []Collector, format string) {
 					"metric":    m.Name,
 					"collector": c.Name(),
 					"set":       m.Set.String(),
-					"type":      strings.ToLower(m.ValueType.ToDTO().String()),
+					"type":      m.TypeName(),
 					"labels":    m.Labels,
 					"help":      m.Description,
 				})
@@ -113,16 +150,3 @@ func PrintMetrics(collectors []Collector, format string) {
 		_ = e.Encode(jsonList)
 	}
 }
-
-func CollectorEnabled(c Collector) bool {
-	collectors := config.Collectors()
-	if len(collectors) == 0 {
-		return true
-	}
-	for _, name := range collectors {
-		if name == c.Name() {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,219 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/sb"
+	"github.com/ovn-org/libovsdb/model"
+)
+
+// Collector settings that are common to all the targets of an exporter.
+type Settings struct {
+	// Number of logical flows exported by the lflow collector.
+	LflowTopN int
+	// Integration bridge tables dumped by the lflow collector. Empty
+	// means the logical ingress and egress pipeline tables.
+	LflowTables []uint8
+	// Resolve the OVN router port tunnel keys to names.
+	ResolveOvnNames bool
+	// Integration bridge tables used by ovn-controller.
+	OvnTables openflow.OvnTables
+}
+
+const defaultIntBridge = "br-int"
+
+// Target is one OVS/OVN instance from which metrics are collected. It owns
+// the clients used to talk to its daemons.
+type Target struct {
+	config.Target
+	Settings
+	Appctl   *appctl.Client
+	Ovsdb    *ovsdb.Client
+	Openflow *openflow.Client
+	Nbdb     *ovsdb.Client
+
+	sbLock sync.Mutex
+	sb     *ovsdb.Client
+	sbKey  string
+
+	stateLock sync.Mutex
+	state     map[string]any
+}
+
+func NewTarget(t config.Target, s Settings) *Target {
+	nb := t.OvnNbRemote
+	if nb == "" {
+		nb = fmt.Sprintf("unix:%s/ovnnb_db.sock", t.OvsdbRundir)
+	}
+	return &Target{
+		Target:   t,
+		Settings: s,
+		Appctl:   appctl.NewClient(t.OvsRundir, t.OvnRundir, t.OvsdbRundir),
+		Ovsdb:    ovsdb.NewClient(t.OvsRundir),
+		Openflow: openflow.NewClient(t.OvsRundir),
+		Nbdb:     ovsdb.NewRemoteClient("OVN_Northbound", nb, nil, nil),
+	}
+}
+
+// Southbound tables that can be read with ovsdb.ListFrom. Each one is only
+// monitored once read. Only the columns present in the models are received.
+func sbTables() map[string]model.Model {
+	return map[string]model.Model{
+		"Chassis":          &sb.Chassis{},
+		"Datapath_Binding": &sb.DatapathBinding{},
+		"FDB":              &sb.FDB{},
+		"Logical_DP_Group": &sb.LogicalDPGroup{},
+		"Logical_Flow":     &sb.LogicalFlow{},
+		"MAC_Binding":      &sb.MACBinding{},
+		"Port_Binding":     &sb.PortBinding{},
+	}
+}
+
+type targetKey struct{}
+
+// Return a copy of ctx that carries the target and its clients. All appctl,
+// ovsdb and openflow calls made with the returned context are sent to the
+// daemons of this target.
+func (t *Target) Bind(ctx context.Context) context.Context {
+	ctx = context.WithValue(ctx, targetKey{}, t)
+	ctx = appctl.WithClient(ctx, t.Appctl)
+	ctx = ovsdb.WithClient(ctx, t.Ovsdb)
+	return openflow.WithClient(ctx, t.Openflow)
+}
+
+// Return the name of the OVN integration bridge of the target.
+func (t *Target) IntBridge() string {
+	if t.IntBrdNam == "" {
+		return defaultIntBridge
+	}
+	return t.IntBrdNam
+}
+
+// Return the target bound to the context, if any.
+func TargetFrom(ctx context.Context) *Target {
+	t, _ := ctx.Value(targetKey{}).(*Target)
+	return t
+}
+
+// Return the files referenced by the SSL table of the Open_vSwitch database.
+// They are used by ovn-controller to connect to "ssl:" remotes.
+func getSSLFiles(ctx context.Context, vswitch *ovs.OpenvSwitch) (*ovsdb.SSLFiles, error) {
+	if vswitch.SSL == nil {
+		return nil, errors.New("ovn-remote uses ssl but no SSL is configured")
+	}
+	var ssl []ovs.SSL
+	if err := ovsdb.List(ctx, &ssl); err != nil {
+		return nil, fmt.Errorf("db.List(SSL): %w", err)
+	}
+	for _, s := range ssl {
+		if s.UUID == *vswitch.SSL {
+			return &ovsdb.SSLFiles{
+				PrivateKey:  s.PrivateKey,
+				Certificate: s.Certificate,
+				CACert:      s.CaCert,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("SSL %s not found", *vswitch.SSL)
+}
+
+// Return the remote of the Southbound database and the SSL files needed to
+// connect to it, if any. The ovn-sb-remote setting takes precedence. Otherwise,
+// the database ovn-controller is connected to is used (the ovn-remote external
+// id of the Open_vSwitch table). If ovn-controller is not configured, the
+// ovnsb_db.sock socket located in ovsdb-rundir is used.
+func (t *Target) sbRemote(ctx context.Context) (string, *ovsdb.SSLFiles, error) {
+	if t.OvnSbRemote != "" {
+		return t.OvnSbRemote, nil, nil
+	}
+
+	var vswitch ovs.OpenvSwitch
+	if err := ovsdb.Get(ctx, &vswitch); err != nil {
+		return "", nil, fmt.Errorf("db.Get(Open_vSwitch): %w", err)
+	}
+	remote := vswitch.ExternalIDs["ovn-remote"]
+	if remote == "" {
+		return fmt.Sprintf("unix:%s/ovnsb_db.sock", t.OvsdbRundir), nil, nil
+	}
+	if !strings.Contains(remote, "ssl:") {
+		return remote, nil, nil
+	}
+	ssl, err := getSSLFiles(ctx, &vswitch)
+	if err != nil {
+		return "", nil, err
+	}
+	return remote, ssl, nil
+}
+
+// Return the client of the Southbound database of the target, see sbRemote.
+// ctx must be bound to the target. The client is replaced when the remote or
+// the SSL files change.
+func (t *Target) Sbdb(ctx context.Context) (*ovsdb.Client, error) {
+	remote, ssl, err := t.sbRemote(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t.sbLock.Lock()
+	defer t.sbLock.Unlock()
+
+	key := remote
+	if ssl != nil {
+		key = fmt.Sprintf("%s %+v", remote, *ssl)
+	}
+	if t.sb != nil && t.sbKey == key {
+		return t.sb, nil
+	}
+	if t.sb != nil {
+		t.sb.Close()
+	}
+	t.sb = ovsdb.NewRemoteClient("OVN_Southbound", remote, ssl, sbTables())
+	t.sbKey = key
+
+	return t.sb, nil
+}
+
+// Return the current Southbound database client, if any.
+func (t *Target) CurrentSbdb() *ovsdb.Client {
+	t.sbLock.Lock()
+	defer t.sbLock.Unlock()
+	return t.sb
+}
+
+// Return the state kept by a collector between scrapes of the target. It is
+// created with init on first use. The collector is responsible for locking it,
+// scrapes may run concurrently.
+func (t *Target) CollectorState(name string, init func() any) any {
+	t.stateLock.Lock()
+	defer t.stateLock.Unlock()
+	if t.state == nil {
+		t.state = make(map[string]any)
+	}
+	s, ok := t.state[name]
+	if !ok {
+		s = init()
+		t.state[name] = s
+	}
+	return s
+}
+
+// Release all connections of the target.
+func (t *Target) Close() {
+	t.Ovsdb.Close()
+	t.Nbdb.Close()
+	if sb := t.CurrentSbdb(); sb != nil {
+		sb.Close()
+	}
+}
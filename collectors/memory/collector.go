@@ -4,12 +4,12 @@
 package memory
 
 import (
+	"context"
 	"regexp"
 	"strconv"
 
 	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
-	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -28,16 +28,12 @@ func (Collector) Metrics() []lib.Metric {
 	return res
 }
 
-func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
-	lib.DescribeEnabledMetrics(c, ch)
-}
-
 var memoryCountRe = regexp.MustCompile(`(\w+):(\d+)`)
 
-func (Collector) Collect(ch chan<- prometheus.Metric) {
-	buf := appctl.OvsVSwitchd("memory/show")
-	if buf == "" {
-		return
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	buf, err := appctl.OvsVSwitchd(ctx, "memory/show")
+	if err != nil {
+		return err
 	}
 
 	for _, match := range memoryCountRe.FindAllStringSubmatch(buf, -1) {
@@ -45,7 +41,7 @@ func (Collector) Collect(ch chan<- prometheus.Metric) {
 		if !ok {
 			continue
 		}
-		if !config.MetricSets().Has(m.Set) {
+		if !lib.MetricSets(ctx).Has(m.Set) {
 			continue
 		}
 		val, err := strconv.ParseFloat(match[2], 64)
@@ -55,4 +51,6 @@ func (Collector) Collect(ch chan<- prometheus.Metric) {
 		}
 		ch <- prometheus.MustNewConstMetric(m.Desc(), m.ValueType, val)
 	}
+
+	return nil
 }
@@ -6,14 +6,14 @@ package ovn
 import (
 	"bufio"
 	"context"
+	"errors"
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
-	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
@@ -21,13 +21,13 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-func collectopenvSwitch(externaIds map[string]string, ch chan<- prometheus.Metric) {
+func collectopenvSwitch(ctx context.Context, externaIds map[string]string, ch chan<- prometheus.Metric) {
 	for name, metric := range openvSwitch {
 		value, ok := externaIds[name]
 		if !ok {
 			continue
 		}
-		if !config.MetricSets().Has(metric.Set) {
+		if !lib.MetricSets(ctx).Has(metric.Set) {
 			continue
 		}
 
@@ -41,13 +41,13 @@ func collectopenvSwitch(externaIds map[string]string, ch chan<- prometheus.Metri
 	}
 }
 
-func collectopenvSwitchBoolean(externaIds map[string]string, ch chan<- prometheus.Metric) {
+func collectopenvSwitchBoolean(ctx context.Context, externaIds map[string]string, ch chan<- prometheus.Metric) {
 	for name, metric := range openvSwitchBoolean {
 		value, ok := externaIds[name]
 		if !ok {
 			continue
 		}
-		if !config.MetricSets().Has(metric.Set) {
+		if !lib.MetricSets(ctx).Has(metric.Set) {
 			continue
 		}
 
@@ -73,14 +73,14 @@ func parse_mappings(bridgeMappings string) map[string]string {
 	return mappings
 }
 
-func collectopenvSwitchLabels(externaIds map[string]string, ch chan<- prometheus.Metric) {
+func collectopenvSwitchLabels(ctx context.Context, externaIds map[string]string, ch chan<- prometheus.Metric) {
 	value := 1.0
 	for name, metric := range openvSwitchLabels {
 		label, ok := externaIds[name]
 		if !ok {
 			continue
 		}
-		if !config.MetricSets().Has(metric.Set) {
+		if !lib.MetricSets(ctx).Has(metric.Set) {
 			continue
 		}
 
@@ -91,7 +91,7 @@ func collectopenvSwitchLabels(externaIds map[string]string, ch chan<- prometheus
 	if !ok {
 		return
 	}
-	if !config.MetricSets().Has(bridgeMappings.Set) {
+	if !lib.MetricSets(ctx).Has(bridgeMappings.Set) {
 		return
 	}
 	for network, bridge := range parse_mappings(extIds) {
@@ -100,12 +100,12 @@ func collectopenvSwitchLabels(externaIds map[string]string, ch chan<- prometheus
 	}
 }
 
-func makeMetric(name, value string) prometheus.Metric {
+func makeMetric(ctx context.Context, name, value string) prometheus.Metric {
 	m, ok := ovnController[name]
 	if !ok {
 		return nil
 	}
-	if !config.MetricSets().Has(m.Set) {
+	if !lib.MetricSets(ctx).Has(m.Set) {
 		return nil
 	}
 
@@ -135,16 +135,16 @@ func isPacketInDropComponent(name string) bool {
 // "vconn_sent                 0.0/sec     0.083/sec        0.0767/sec   total: 131870"
 var coverageRe = regexp.MustCompile(`^(\w+)\s+.*\s+total: (\d+)$`)
 
-func collectCoverageMetrics(ch chan<- prometheus.Metric) {
+func collectCoverageMetrics(ctx context.Context, ch chan<- prometheus.Metric) error {
 
 	packetInDropComponets := map[string]string{
 		dropBufferedPacketsMap: "",
 		dropControllerEvent:    "",
 	}
 
-	buf := appctl.OvnController("coverage/show")
-	if buf == "" {
-		return
+	buf, err := appctl.OvnController(ctx, "coverage/show")
+	if err != nil {
+		return err
 	}
 
 	scanner := bufio.NewScanner(strings.NewReader(buf))
@@ -156,7 +156,7 @@ func collectCoverageMetrics(ch chan<- prometheus.Metric) {
 			if isPacketInDropComponent(match[1]) {
 				packetInDropComponets[match[1]] = match[2]
 			} else {
-				metric := makeMetric(match[1], match[2])
+				metric := makeMetric(ctx, match[1], match[2])
 				if metric != nil {
 					ch <- metric
 				}
@@ -176,30 +176,48 @@ func collectCoverageMetrics(ch chan<- prometheus.Metric) {
 		}
 	}
 	if total > 0 {
-		metric := makeMetric(packetInDrop, strconv.Itoa(total))
+		metric := makeMetric(ctx, packetInDrop, strconv.Itoa(total))
 		if metric != nil {
 			ch <- metric
 		}
 	}
+
+	return nil
 }
 
-func collectLogicalRouters(ch chan<- prometheus.Metric) {
+func collectLogicalRouters(ctx context.Context, ch chan<- prometheus.Metric) error {
 	var value float64
+	var names map[routerPortKey]routerPortNames
+
+	t := lib.TargetFrom(ctx)
+	if t == nil {
+		return errors.New("no target in context")
+	}
 
-	rps, err := openflow.GetRouterPortsStats()
+	rps, err := openflow.GetRouterPortsStats(ctx, t.IntBridge(), t.OvnTables)
 	if err != nil {
-		log.Errf("Error getting router ports statistics: %s", err)
-		return
+		return fmt.Errorf("error getting router ports statistics: %w", err)
+	}
+
+	if t.ResolveOvnNames && len(rps) > 0 {
+		// still report the metrics without names on failure, the
+		// database reachability is reported in endpoint_up
+		names, err = getRouterPortNames(ctx, t)
+		if err != nil {
+			log.Errf("router port names: %s", err)
+		}
 	}
 
 	for _, s := range rps {
+		n := names[routerPortKey{datapath: s.DPTunnelKey, port: s.PortTunnelKey}]
 		labels := []string{
 			strconv.FormatUint(s.DPTunnelKey, 10),
 			strconv.FormatUint(uint64(s.PortTunnelKey), 10),
+			n.datapath, n.port, n.routerID, n.portID,
 		}
 
 		for name, metric := range ovnRouterPortTraffic {
-			if !config.MetricSets().Has(metric.Set) {
+			if !lib.MetricSets(ctx).Has(metric.Set) {
 				continue
 			}
 			if name == routerporttrafficpkts {
@@ -211,6 +229,8 @@ func collectLogicalRouters(ch chan<- prometheus.Metric) {
 			ch <- prometheus.MustNewConstMetric(metric.Desc(), metric.ValueType, value, labels...)
 		}
 	}
+
+	return nil
 }
 
 type Collector struct{}
@@ -230,27 +250,29 @@ func (Collector) Metrics() []lib.Metric {
 	return res
 }
 
-func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
-	lib.DescribeEnabledMetrics(c, ch)
-}
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	var errs []error
 
-func (Collector) Collect(ch chan<- prometheus.Metric) {
 	// collect items from the ExternalIDs field in the OpenvSwitch table
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-	defer cancel()
 	var vswitch ovs.OpenvSwitch
 	err := ovsdb.Get(ctx, &vswitch)
 	if err != nil {
-		log.Errf("OvsdbGet(vswitch): %s", err)
-		return
+		errs = append(errs, fmt.Errorf("OvsdbGet(vswitch): %w", err))
+	} else {
+		collectopenvSwitch(ctx, vswitch.ExternalIDs, ch)
+		collectopenvSwitchBoolean(ctx, vswitch.ExternalIDs, ch)
+		collectopenvSwitchLabels(ctx, vswitch.ExternalIDs, ch)
 	}
-	collectopenvSwitch(vswitch.ExternalIDs, ch)
-	collectopenvSwitchBoolean(vswitch.ExternalIDs, ch)
-	collectopenvSwitchLabels(vswitch.ExternalIDs, ch)
 
 	// collect the ovn-controller coverage metrics
-	collectCoverageMetrics(ch)
+	if err := collectCoverageMetrics(ctx, ch); err != nil {
+		errs = append(errs, err)
+	}
 
 	// collect the logical router and logical router ports metrics
-	collectLogicalRouters(ch)
+	if err := collectLogicalRouters(ctx, ch); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
 }
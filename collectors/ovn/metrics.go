@@ -197,18 +197,22 @@ var ovnController = map[string]lib.Metric{
 	},
 }
 
+var routerPortLabels = []string{
+	"datapath", "port", "datapath_name", "port_name", "router_id", "port_id",
+}
+
 var ovnRouterPortTraffic = map[string]lib.Metric{
 	routerporttrafficpkts: {
 		Name:        "ovnc_router_port_traffic_pkts",
 		Description: "Number of packets transmitted and received by a logical router port labeled by the logical datapath number and the logical port number",
-		Labels:      []string{"datapath", "port"},
+		Labels:      routerPortLabels,
 		ValueType:   prometheus.GaugeValue,
 		Set:         config.METRICS_BASE,
 	},
 	"ovn-router-port-traffic-bytes": {
 		Name:        "ovnc_router_port_traffic_bytes",
 		Description: "Number of bytes transmitted and received by a logical router port labeled by the logical datapath number and the logical port number",
-		Labels:      []string{"datapath", "port"},
+		Labels:      routerPortLabels,
 		ValueType:   prometheus.GaugeValue,
 		Set:         config.METRICS_BASE,
 	},
@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package ovn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/sb"
+)
+
+// Tunnel keys of a logical router port, as found in the openflow rules.
+type routerPortKey struct {
+	datapath uint64
+	port     uint32
+}
+
+// Names of a logical router port resolved from the Southbound database.
+type routerPortNames struct {
+	datapath string
+	port     string
+	routerID string
+	portID   string
+}
+
+// Resolve the tunnel keys of all logical router ports to their names using
+// the Southbound database of the target, by default the one ovn-controller is
+// connected to. The Datapath_Binding and Port_Binding tables are monitored so
+// that the mapping is kept up to date without querying the whole tables on
+// every scrape.
+func getRouterPortNames(
+	ctx context.Context, t *lib.Target,
+) (map[routerPortKey]routerPortNames, error) {
+	db, err := t.Sbdb(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var datapaths []sb.DatapathBinding
+	var ports []sb.PortBinding
+
+	if err := ovsdb.ListFrom(ctx, db, &datapaths); err != nil {
+		return nil, fmt.Errorf("db.List(Datapath_Binding): %w", err)
+	}
+	if err := ovsdb.ListFrom(ctx, db, &ports); err != nil {
+		return nil, fmt.Errorf("db.List(Port_Binding): %w", err)
+	}
+
+	routers := make(map[string]*sb.DatapathBinding)
+	for d := range datapaths {
+		if _, ok := datapaths[d].ExternalIDs["logical-router"]; ok {
+			routers[datapaths[d].UUID] = &datapaths[d]
+		}
+	}
+
+	names := make(map[routerPortKey]routerPortNames)
+	for _, p := range ports {
+		dp, ok := routers[p.Datapath]
+		if !ok {
+			continue
+		}
+		// ovn-northd copies the neutron:router_name external id of the
+		// logical router to name2, neutron names logical routers
+		// "neutron-<router id>" and their ports "lrp-<port id>"
+		n := routerPortNames{
+			datapath: dp.ExternalIDs["name2"],
+			port:     p.LogicalPort,
+		}
+		name := dp.ExternalIDs["name"]
+		if n.datapath == "" {
+			n.datapath = name
+		}
+		if id, ok := strings.CutPrefix(name, "neutron-"); ok {
+			n.routerID = id
+		}
+		if id, ok := strings.CutPrefix(p.LogicalPort, "lrp-"); ok {
+			n.portID = id
+		}
+		key := routerPortKey{datapath: uint64(dp.TunnelKey), port: uint32(p.TunnelKey)}
+		names[key] = n
+	}
+
+	return names, nil
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package ovn
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovsdbtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/sb"
+	"github.com/ovn-org/libovsdb/model"
+)
+
+func TestGetRouterPortNames(t *testing.T) {
+	tests := []struct {
+		name string
+		rows []model.Model
+		want map[routerPortKey]routerPortNames
+	}{
+		{
+			name: "neutron router",
+			rows: []model.Model{
+				&sb.DatapathBinding{
+					UUID:      "router",
+					TunnelKey: 3,
+					ExternalIDs: map[string]string{
+						"logical-router": "5a1d4c2e-8f3b-4a6d-9e7c-1b2a3c4d5e6f",
+						"name":           "neutron-0c1d2e3f-4a5b-4c6d-8e7f-9a0b1c2d3e4f",
+						"name2":          "router1",
+					},
+				},
+				&sb.PortBinding{
+					Datapath:    "router",
+					TunnelKey:   2,
+					LogicalPort: "lrp-7a8b9c0d-1e2f-4a3b-9c4d-5e6f7a8b9c0d",
+				},
+				&sb.PortBinding{
+					Datapath:    "router",
+					TunnelKey:   1,
+					LogicalPort: "cr-gw",
+				},
+				&sb.DatapathBinding{
+					UUID:      "switch",
+					TunnelKey: 4,
+					ExternalIDs: map[string]string{
+						"logical-switch": "6b2e5d3f-9a4c-4b7e-8f8d-2c3b4d5e6f7a",
+						"name":           "neutron-1d2e3f4a-5b6c-4d7e-8f9a-0b1c2d3e4f5a",
+					},
+				},
+				&sb.PortBinding{
+					Datapath:    "switch",
+					TunnelKey:   2,
+					LogicalPort: "8c9d0e1f-2a3b-4c4d-9e5f-6a7b8c9d0e1f",
+				},
+			},
+			want: map[routerPortKey]routerPortNames{
+				{datapath: 3, port: 2}: {
+					datapath: "router1",
+					port:     "lrp-7a8b9c0d-1e2f-4a3b-9c4d-5e6f7a8b9c0d",
+					routerID: "0c1d2e3f-4a5b-4c6d-8e7f-9a0b1c2d3e4f",
+					portID:   "7a8b9c0d-1e2f-4a3b-9c4d-5e6f7a8b9c0d",
+				},
+				{datapath: 3, port: 1}: {
+					datapath: "router1",
+					port:     "cr-gw",
+					routerID: "0c1d2e3f-4a5b-4c6d-8e7f-9a0b1c2d3e4f",
+				},
+			},
+		},
+		{
+			name: "plain ovn router",
+			rows: []model.Model{
+				&sb.DatapathBinding{
+					UUID:      "router",
+					TunnelKey: 1,
+					ExternalIDs: map[string]string{
+						"logical-router": "5a1d4c2e-8f3b-4a6d-9e7c-1b2a3c4d5e6f",
+						"name":           "lr0",
+					},
+				},
+				&sb.PortBinding{
+					Datapath:    "router",
+					TunnelKey:   1,
+					LogicalPort: "lr0-public",
+				},
+			},
+			want: map[routerPortKey]routerPortNames{
+				{datapath: 1, port: 1}: {datapath: "lr0", port: "lr0-public"},
+			},
+		},
+		{
+			name: "empty database",
+			want: map[routerPortKey]routerPortNames{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientModel, err := sb.FullDatabaseModel()
+			if err != nil {
+				t.Fatal(err)
+			}
+			remote := ovsdbtest.Serve(t, sb.Schema(), clientModel, tt.rows...)
+
+			target := lib.NewTarget(config.Target{OvnSbRemote: remote}, lib.Settings{})
+			t.Cleanup(target.Close)
+
+			got, err := getRouterPortNames(target.Bind(context.Background()), target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("got:\n%v\nwant:\n%v", got, tt.want)
+			}
+		})
+	}
+}
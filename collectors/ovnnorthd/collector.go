@@ -5,13 +5,15 @@ package ovnnorthd
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
-	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -31,16 +33,12 @@ func (Collector) Metrics() []lib.Metric {
 	return res
 }
 
-func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
-	lib.DescribeEnabledMetrics(c, ch)
-}
-
-func makeMetric(name, value string) prometheus.Metric {
+func makeMetric(ctx context.Context, name, value string) prometheus.Metric {
 	m, ok := coverageMetrics[name]
 	if !ok {
 		return nil
 	}
-	if !config.MetricSets().Has(m.Set) {
+	if !lib.MetricSets(ctx).Has(m.Set) {
 		return nil
 	}
 
@@ -56,10 +54,10 @@ func makeMetric(name, value string) prometheus.Metric {
 // "pstream_open                 0.0/sec     0.000/sec        0.0000/sec   total: 1"
 var coverageRe = regexp.MustCompile(`^(\w+)\s+.*\s+total: (\d+)$`)
 
-func collectCoverageMetrics(ch chan<- prometheus.Metric) {
-	buf := appctl.OvnNorthd("coverage/show")
-	if buf == "" {
-		return
+func collectCoverageMetrics(ctx context.Context, ch chan<- prometheus.Metric) error {
+	buf, err := appctl.OvnNorthd(ctx, "coverage/show")
+	if err != nil {
+		return err
 	}
 
 	scanner := bufio.NewScanner(strings.NewReader(buf))
@@ -68,22 +66,24 @@ func collectCoverageMetrics(ch chan<- prometheus.Metric) {
 
 		match := coverageRe.FindStringSubmatch(line)
 		if match != nil {
-			metric := makeMetric(match[1], match[2])
+			metric := makeMetric(ctx, match[1], match[2])
 			if metric != nil {
 				ch <- metric
 			}
 		}
 	}
+
+	return nil
 }
 
-func collectStatusMetric(ch chan<- prometheus.Metric) {
-	if !config.MetricSets().Has(statusMetric.Set) {
-		return
+func collectStatusMetric(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if !lib.MetricSets(ctx).Has(statusMetric.Set) {
+		return nil
 	}
 
-	buf := appctl.OvnNorthd("status")
-	if buf == "" {
-		return
+	buf, err := appctl.OvnNorthd(ctx, "status")
+	if err != nil {
+		return err
 	}
 
 	var value float64
@@ -102,25 +102,26 @@ func collectStatusMetric(ch chan<- prometheus.Metric) {
 			case "paused":
 				value = 2.0
 			default:
-				log.Warningf("Unknown northd status: %s", statusValue)
-				return
+				return fmt.Errorf("unknown northd status: %s", statusValue)
 			}
 		} else {
-			log.Warningf("Unexpected status format: %s", status)
-			return
+			return fmt.Errorf("unexpected status format: %s", status)
 		}
 	} else {
-		log.Warningf("Status output does not contain 'Status:' prefix: %s", status)
-		return
+		return fmt.Errorf("status output does not contain 'Status:' prefix: %s", status)
 	}
 
 	ch <- prometheus.MustNewConstMetric(statusMetric.Desc(), statusMetric.ValueType, value)
+
+	return nil
 }
 
-func (Collector) Collect(ch chan<- prometheus.Metric) {
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
 	// Collect coverage metrics
-	collectCoverageMetrics(ch)
+	errCoverage := collectCoverageMetrics(ctx, ch)
 
 	// Collect status metric
-	collectStatusMetric(ch)
+	errStatus := collectStatusMetric(ctx, ch)
+
+	return errors.Join(errCoverage, errStatus)
 }
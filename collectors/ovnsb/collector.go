@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package ovnsb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/sb"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Collector struct{}
+
+func (Collector) Name() string {
+	return "ovnsb"
+}
+
+func (Collector) Metrics() []lib.Metric {
+	return []lib.Metric{
+		chassisMetric, datapathBindingsMetric,
+		portBindingsMetric, chassisPortBindingsMetric,
+		logicalFlowsMetric, datapathLogicalFlowsMetric,
+		macBindingsMetric, fdbEntriesMetric,
+	}
+}
+
+// Return the type of a logical datapath from the external ids set by
+// ovn-northd.
+func datapathType(dp *sb.DatapathBinding) string {
+	if _, ok := dp.ExternalIDs["logical-router"]; ok {
+		return "router"
+	}
+	if _, ok := dp.ExternalIDs["logical-switch"]; ok {
+		return "switch"
+	}
+	return ""
+}
+
+// Return the tunnel key and name labels of a logical datapath. ovn-northd
+// copies the neutron network or router name to name2, if any.
+func datapathLabelValues(dp *sb.DatapathBinding) []string {
+	name := dp.ExternalIDs["name2"]
+	if name == "" {
+		name = dp.ExternalIDs["name"]
+	}
+	return []string{strconv.Itoa(dp.TunnelKey), name}
+}
+
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	t := lib.TargetFrom(ctx)
+	if t == nil {
+		return errors.New("no target in context")
+	}
+	if t.OvnSbRemote == "" {
+		// opt-in, the monitored tables may use a lot of memory and
+		// there is no Southbound database on compute nodes
+		return nil
+	}
+	db, err := t.Sbdb(ctx)
+	if err != nil {
+		return err
+	}
+
+	var chassis []sb.Chassis
+	var datapaths []sb.DatapathBinding
+	var ports []sb.PortBinding
+
+	if err := ovsdb.ListFrom(ctx, db, &chassis); err != nil {
+		return fmt.Errorf("db.List(Chassis): %w", err)
+	}
+	if err := ovsdb.ListFrom(ctx, db, &datapaths); err != nil {
+		return fmt.Errorf("db.List(Datapath_Binding): %w", err)
+	}
+	if err := ovsdb.ListFrom(ctx, db, &ports); err != nil {
+		return fmt.Errorf("db.List(Port_Binding): %w", err)
+	}
+
+	metric := func(m *lib.Metric, val int, labels ...string) {
+		if lib.MetricSets(ctx).Has(m.Set) {
+			ch <- prometheus.MustNewConstMetric(
+				m.Desc(), m.ValueType, float64(val), labels...)
+		}
+	}
+
+	metric(&chassisMetric, len(chassis))
+
+	dpByUUID := make(map[string]*sb.DatapathBinding)
+	dpByKey := make(map[int]*sb.DatapathBinding)
+	dpTypes := map[string]int{"router": 0, "switch": 0}
+	for d := range datapaths {
+		dp := &datapaths[d]
+		dpByUUID[dp.UUID] = dp
+		dpByKey[dp.TunnelKey] = dp
+		dpTypes[datapathType(dp)]++
+	}
+	for typ, count := range dpTypes {
+		metric(&datapathBindingsMetric, count, typ)
+	}
+
+	portTypes := make(map[string]int)
+	chassisPorts := make(map[string]int)
+	for _, p := range ports {
+		portTypes[p.Type]++
+		if p.Chassis != nil {
+			chassisPorts[*p.Chassis]++
+		}
+	}
+	for typ, count := range portTypes {
+		metric(&portBindingsMetric, count, typ)
+	}
+	for _, c := range chassis {
+		metric(&chassisPortBindingsMetric, chassisPorts[c.UUID], c.Name, c.Hostname)
+	}
+
+	if err := collectLogicalFlows(ctx, db, dpByUUID, metric); err != nil {
+		return err
+	}
+
+	var macBindings []sb.MACBinding
+	if err := ovsdb.ListFrom(ctx, db, &macBindings); err != nil {
+		return fmt.Errorf("db.List(MAC_Binding): %w", err)
+	}
+	dpMacBindings := make(map[*sb.DatapathBinding]int)
+	for _, m := range macBindings {
+		if dp, ok := dpByUUID[m.Datapath]; ok {
+			dpMacBindings[dp]++
+		}
+	}
+	for dp, count := range dpMacBindings {
+		metric(&macBindingsMetric, count, datapathLabelValues(dp)...)
+	}
+
+	var fdb []sb.FDB
+	if err := ovsdb.ListFrom(ctx, db, &fdb); err != nil {
+		return fmt.Errorf("db.List(FDB): %w", err)
+	}
+	// FDB entries reference their datapath by tunnel key
+	dpFdbEntries := make(map[*sb.DatapathBinding]int)
+	for _, f := range fdb {
+		if dp, ok := dpByKey[f.DpKey]; ok {
+			dpFdbEntries[dp]++
+		}
+	}
+	for dp, count := range dpFdbEntries {
+		metric(&fdbEntriesMetric, count, datapathLabelValues(dp)...)
+	}
+
+	return nil
+}
+
+type metricFunc func(m *lib.Metric, val int, labels ...string)
+
+type datapathPipeline struct {
+	dp       *sb.DatapathBinding
+	pipeline string
+}
+
+func collectLogicalFlows(
+	ctx context.Context, db *ovsdb.Client,
+	dpByUUID map[string]*sb.DatapathBinding, metric metricFunc,
+) error {
+	var groups []sb.LogicalDPGroup
+	var lflows []sb.LogicalFlow
+
+	if err := ovsdb.ListFrom(ctx, db, &groups); err != nil {
+		return fmt.Errorf("db.List(Logical_DP_Group): %w", err)
+	}
+	if err := ovsdb.ListFrom(ctx, db, &lflows); err != nil {
+		return fmt.Errorf("db.List(Logical_Flow): %w", err)
+	}
+
+	groupDatapaths := make(map[string][]string)
+	for _, g := range groups {
+		groupDatapaths[g.UUID] = g.Datapaths
+	}
+
+	pipelines := map[string]int{
+		sb.LogicalFlowPipelineIngress: 0,
+		sb.LogicalFlowPipelineEgress:  0,
+	}
+	dpFlows := make(map[datapathPipeline]int)
+	add := func(dpUUID, pipeline string) {
+		if dp, ok := dpByUUID[dpUUID]; ok {
+			dpFlows[datapathPipeline{dp: dp, pipeline: pipeline}]++
+		}
+	}
+
+	for _, f := range lflows {
+		pipelines[f.Pipeline]++
+		if f.LogicalDatapath != nil {
+			add(*f.LogicalDatapath, f.Pipeline)
+		}
+		if f.LogicalDpGroup != nil {
+			for _, dp := range groupDatapaths[*f.LogicalDpGroup] {
+				add(dp, f.Pipeline)
+			}
+		}
+	}
+
+	for pipeline, count := range pipelines {
+		metric(&logicalFlowsMetric, count, pipeline)
+	}
+	for key, count := range dpFlows {
+		labels := append(datapathLabelValues(key.dp), key.pipeline)
+		metric(&datapathLogicalFlowsMetric, count, labels...)
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package ovnsb
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib/libtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovsdbtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/sb"
+	"github.com/ovn-org/libovsdb/model"
+)
+
+func ptr(s string) *string {
+	return &s
+}
+
+// a neutron network and router on two chassis
+var rows = []model.Model{
+	&sb.Chassis{UUID: "ch1", Name: "6d3a1b2c-0e4f-4a5b-8c6d-7e8f9a0b1c2d", Hostname: "compute-0"},
+	&sb.Chassis{UUID: "ch2", Name: "7e4b2c3d-1f5a-4b6c-9d7e-8f9a0b1c2d3e", Hostname: "compute-1"},
+	&sb.DatapathBinding{
+		UUID:      "net",
+		TunnelKey: 1,
+		ExternalIDs: map[string]string{
+			"logical-switch": "6b2e5d3f-9a4c-4b7e-8f8d-2c3b4d5e6f7a",
+			"name":           "neutron-1d2e3f4a-5b6c-4d7e-8f9a-0b1c2d3e4f5a",
+			"name2":          "private",
+		},
+	},
+	&sb.DatapathBinding{
+		UUID:      "router",
+		TunnelKey: 2,
+		ExternalIDs: map[string]string{
+			"logical-router": "5a1d4c2e-8f3b-4a6d-9e7c-1b2a3c4d5e6f",
+			"name":           "neutron-0c1d2e3f-4a5b-4c6d-8e7f-9a0b1c2d3e4f",
+			"name2":          "router1",
+		},
+	},
+	&sb.PortBinding{Datapath: "net", TunnelKey: 1, LogicalPort: "vm1", Chassis: ptr("ch1")},
+	&sb.PortBinding{Datapath: "net", TunnelKey: 2, LogicalPort: "vm2", Chassis: ptr("ch1")},
+	&sb.PortBinding{Datapath: "net", TunnelKey: 3, LogicalPort: "net-router", Type: "patch"},
+	&sb.PortBinding{Datapath: "router", TunnelKey: 1, LogicalPort: "lrp-net", Type: "patch"},
+	&sb.LogicalDPGroup{UUID: "group", Datapaths: []string{"net", "router"}},
+	&sb.LogicalFlow{LogicalDatapath: ptr("net"), Pipeline: sb.LogicalFlowPipelineIngress},
+	&sb.LogicalFlow{LogicalDatapath: ptr("net"), Pipeline: sb.LogicalFlowPipelineEgress},
+	&sb.LogicalFlow{LogicalDatapath: ptr("router"), Pipeline: sb.LogicalFlowPipelineIngress},
+	&sb.LogicalFlow{LogicalDpGroup: ptr("group"), Pipeline: sb.LogicalFlowPipelineIngress},
+	&sb.MACBinding{Datapath: "router"},
+	&sb.MACBinding{Datapath: "router"},
+	&sb.FDB{DpKey: 1},
+}
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name   string
+		sets   config.MetricSet
+		remote bool
+		rows   []model.Model
+		want   []string
+	}{
+		{
+			name:   "southbound remote",
+			sets:   config.METRICS_DEFAULT,
+			remote: true,
+			rows:   rows,
+			want: []string{
+				`ovn_sb_chassis 2`,
+				`ovn_sb_chassis_port_bindings{chassis="6d3a1b2c-0e4f-4a5b-8c6d-7e8f9a0b1c2d",hostname="compute-0"} 2`,
+				`ovn_sb_chassis_port_bindings{chassis="7e4b2c3d-1f5a-4b6c-9d7e-8f9a0b1c2d3e",hostname="compute-1"} 0`,
+				`ovn_sb_datapath_bindings{type="router"} 1`,
+				`ovn_sb_datapath_bindings{type="switch"} 1`,
+				`ovn_sb_datapath_logical_flows{datapath="1",datapath_name="private",pipeline="egress"} 1`,
+				`ovn_sb_datapath_logical_flows{datapath="1",datapath_name="private",pipeline="ingress"} 2`,
+				`ovn_sb_datapath_logical_flows{datapath="2",datapath_name="router1",pipeline="ingress"} 2`,
+				`ovn_sb_fdb_entries{datapath="1",datapath_name="private"} 1`,
+				`ovn_sb_logical_flows{pipeline="egress"} 1`,
+				`ovn_sb_logical_flows{pipeline="ingress"} 3`,
+				`ovn_sb_mac_bindings{datapath="2",datapath_name="router1"} 2`,
+				`ovn_sb_port_bindings{type=""} 2`,
+				`ovn_sb_port_bindings{type="patch"} 2`,
+			},
+		},
+		{
+			name:   "base set",
+			sets:   config.METRICS_BASE,
+			remote: true,
+			rows:   rows,
+			want: []string{
+				`ovn_sb_chassis 2`,
+				`ovn_sb_chassis_port_bindings{chassis="6d3a1b2c-0e4f-4a5b-8c6d-7e8f9a0b1c2d",hostname="compute-0"} 2`,
+				`ovn_sb_chassis_port_bindings{chassis="7e4b2c3d-1f5a-4b6c-9d7e-8f9a0b1c2d3e",hostname="compute-1"} 0`,
+				`ovn_sb_datapath_bindings{type="router"} 1`,
+				`ovn_sb_datapath_bindings{type="switch"} 1`,
+				`ovn_sb_fdb_entries{datapath="1",datapath_name="private"} 1`,
+				`ovn_sb_logical_flows{pipeline="egress"} 1`,
+				`ovn_sb_logical_flows{pipeline="ingress"} 3`,
+				`ovn_sb_mac_bindings{datapath="2",datapath_name="router1"} 2`,
+				`ovn_sb_port_bindings{type=""} 2`,
+				`ovn_sb_port_bindings{type="patch"} 2`,
+			},
+		},
+		{
+			name:   "empty database",
+			sets:   config.METRICS_DEFAULT,
+			remote: true,
+			want: []string{
+				`ovn_sb_chassis 0`,
+				`ovn_sb_datapath_bindings{type="router"} 0`,
+				`ovn_sb_datapath_bindings{type="switch"} 0`,
+				`ovn_sb_logical_flows{pipeline="egress"} 0`,
+				`ovn_sb_logical_flows{pipeline="ingress"} 0`,
+			},
+		},
+		{
+			name:   "no southbound remote",
+			sets:   config.METRICS_DEFAULT,
+			remote: false,
+			rows:   rows,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientModel, err := sb.FullDatabaseModel()
+			if err != nil {
+				t.Fatal(err)
+			}
+			remote := ovsdbtest.Serve(t, sb.Schema(), clientModel, tt.rows...)
+			if !tt.remote {
+				remote = ""
+			}
+
+			target := lib.NewTarget(config.Target{OvnSbRemote: remote}, lib.Settings{})
+			t.Cleanup(target.Close)
+			ctx := lib.WithMetricSets(target.Bind(context.Background()), tt.sets)
+
+			got, err := libtest.Collect(ctx, Collector{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got:\n%s\nwant:\n%s",
+					strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package ovnsb
+
+import (
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var datapathLabels = []string{"datapath", "datapath_name"}
+
+var chassisMetric = lib.Metric{
+	Name:        "ovn_sb_chassis",
+	Description: "The number of chassis in the OVN Southbound database.",
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_BASE,
+}
+
+var datapathBindingsMetric = lib.Metric{
+	Name:        "ovn_sb_datapath_bindings",
+	Description: "The number of logical datapaths labeled by type (switch or router).",
+	Labels:      []string{"type"},
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_BASE,
+}
+
+var portBindingsMetric = lib.Metric{
+	Name:        "ovn_sb_port_bindings",
+	Description: "The number of logical port bindings labeled by port type.",
+	Labels:      []string{"type"},
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_BASE,
+}
+
+var chassisPortBindingsMetric = lib.Metric{
+	Name:        "ovn_sb_chassis_port_bindings",
+	Description: "The number of logical port bindings claimed by a chassis.",
+	Labels:      []string{"chassis", "hostname"},
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_BASE,
+}
+
+var logicalFlowsMetric = lib.Metric{
+	Name:        "ovn_sb_logical_flows",
+	Description: "The number of logical flows labeled by pipeline.",
+	Labels:      []string{"pipeline"},
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_BASE,
+}
+
+var datapathLogicalFlowsMetric = lib.Metric{
+	Name:        "ovn_sb_datapath_logical_flows",
+	Description: "The number of logical flows applied to a logical datapath.",
+	Labels:      []string{"datapath", "datapath_name", "pipeline"},
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_PERF,
+}
+
+var macBindingsMetric = lib.Metric{
+	Name:        "ovn_sb_mac_bindings",
+	Description: "The number of MAC bindings of a logical datapath.",
+	Labels:      datapathLabels,
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_BASE,
+}
+
+var fdbEntriesMetric = lib.Metric{
+	Name:        "ovn_sb_fdb_entries",
+	Description: "The number of FDB entries of a logical datapath.",
+	Labels:      datapathLabels,
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_BASE,
+}
@@ -5,14 +5,14 @@ package ovsdbserver
 
 import (
 	"bufio"
+	"context"
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
-	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
-	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -169,60 +169,60 @@ func parseClusterStatus(output string) (*raftClusterInfo, error) {
 	return info, scanner.Err()
 }
 
-func collectRaftMetrics(info *raftClusterInfo, ch chan<- prometheus.Metric) {
+func collectRaftMetrics(ctx context.Context, info *raftClusterInfo, ch chan<- prometheus.Metric) {
 	baseLabels := []string{info.database, info.clusterUUID, info.serverUUID}
 
 	// Cluster election timer
-	if config.MetricSets().Has(clusterElectionTimer.Set) {
+	if lib.MetricSets(ctx).Has(clusterElectionTimer.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			clusterElectionTimer.Desc(), clusterElectionTimer.ValueType,
 			float64(info.electionTimer), baseLabels...)
 	}
 
 	// Cluster ID (constant 1.0)
-	if config.MetricSets().Has(clusterId.Set) {
+	if lib.MetricSets(ctx).Has(clusterId.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			clusterId.Desc(), clusterId.ValueType,
 			1.0, info.database, info.clusterUUID)
 	}
 
 	// Cluster Server ID (constant 1.0)
-	if config.MetricSets().Has(clusterServerId.Set) {
+	if lib.MetricSets(ctx).Has(clusterServerId.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			clusterServerId.Desc(), clusterServerId.ValueType,
 			1.0, baseLabels...)
 	}
 
 	// Cluster Server Role (constant 1.0)
-	if config.MetricSets().Has(clusterServerRole.Set) {
+	if lib.MetricSets(ctx).Has(clusterServerRole.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			clusterServerRole.Desc(), clusterServerRole.ValueType,
 			1.0, append(baseLabels, info.role)...)
 	}
 
 	// Cluster Server Status (constant 1.0)
-	if config.MetricSets().Has(clusterServerStatus.Set) {
+	if lib.MetricSets(ctx).Has(clusterServerStatus.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			clusterServerStatus.Desc(), clusterServerStatus.ValueType,
 			1.0, append(baseLabels, info.status)...)
 	}
 
 	// Cluster Server Vote (constant 1.0)
-	if config.MetricSets().Has(clusterServerVote.Set) {
+	if lib.MetricSets(ctx).Has(clusterServerVote.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			clusterServerVote.Desc(), clusterServerVote.ValueType,
 			1.0, append(baseLabels, info.vote)...)
 	}
 
 	// Cluster Term
-	if config.MetricSets().Has(clusterTerm.Set) {
+	if lib.MetricSets(ctx).Has(clusterTerm.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			clusterTerm.Desc(), clusterTerm.ValueType,
 			float64(info.term), baseLabels...)
 	}
 
 	// Cluster Leader (1.0 if leader, 0.0 if not)
-	if config.MetricSets().Has(clusterLeader.Set) {
+	if lib.MetricSets(ctx).Has(clusterLeader.Set) {
 		var leaderValue float64
 		if info.isLeader {
 			leaderValue = 1.0
@@ -235,42 +235,42 @@ func collectRaftMetrics(info *raftClusterInfo, ch chan<- prometheus.Metric) {
 	}
 
 	// Inbound connections
-	if config.MetricSets().Has(clusterInboundConnectionsTotal.Set) {
+	if lib.MetricSets(ctx).Has(clusterInboundConnectionsTotal.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			clusterInboundConnectionsTotal.Desc(), clusterInboundConnectionsTotal.ValueType,
 			float64(info.inboundConns), baseLabels...)
 	}
 
 	// Outbound connections
-	if config.MetricSets().Has(clusterOutboundConnectionsTotal.Set) {
+	if lib.MetricSets(ctx).Has(clusterOutboundConnectionsTotal.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			clusterOutboundConnectionsTotal.Desc(), clusterOutboundConnectionsTotal.ValueType,
 			float64(info.outboundConns), baseLabels...)
 	}
 
 	// Log entry index
-	if config.MetricSets().Has(logEntryIndex.Set) {
+	if lib.MetricSets(ctx).Has(logEntryIndex.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			logEntryIndex.Desc(), logEntryIndex.ValueType,
 			float64(info.logStart), baseLabels...)
 	}
 
 	// Log index next
-	if config.MetricSets().Has(clusterLogIndexNext.Set) {
+	if lib.MetricSets(ctx).Has(clusterLogIndexNext.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			clusterLogIndexNext.Desc(), clusterLogIndexNext.ValueType,
 			float64(info.logNext), baseLabels...)
 	}
 
 	// Log not committed
-	if config.MetricSets().Has(clusterLogNotCommitted.Set) {
+	if lib.MetricSets(ctx).Has(clusterLogNotCommitted.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			clusterLogNotCommitted.Desc(), clusterLogNotCommitted.ValueType,
 			float64(info.logNotCommitted), baseLabels...)
 	}
 
 	// Log not applied
-	if config.MetricSets().Has(clusterLogNotApplied.Set) {
+	if lib.MetricSets(ctx).Has(clusterLogNotApplied.Set) {
 		ch <- prometheus.MustNewConstMetric(
 			clusterLogNotApplied.Desc(), clusterLogNotApplied.ValueType,
 			float64(info.logNotApplied), baseLabels...)
@@ -287,22 +287,18 @@ func (Collector) Metrics() []lib.Metric {
 	return metrics
 }
 
-func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
-	lib.DescribeEnabledMetrics(c, ch)
-}
-
-func (Collector) Collect(ch chan<- prometheus.Metric) {
-	output := appctl.OvsDbServer("cluster/status")
-	if output == "" {
-		log.Debugf("No OVN Raft cluster status output available")
-		return
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	output, err := appctl.OvsDbServer(ctx, "cluster/status")
+	if err != nil {
+		return err
 	}
 
 	info, err := parseClusterStatus(output)
 	if err != nil {
-		log.Errf("Failed to parse OVN Raft cluster status: %s", err)
-		return
+		return fmt.Errorf("failed to parse OVN Raft cluster status: %w", err)
 	}
 
-	collectRaftMetrics(info, ch)
+	collectRaftMetrics(ctx, info, ch)
+
+	return nil
 }
@@ -5,23 +5,25 @@ package pmd_perf
 
 import (
 	"bufio"
+	"context"
+	"errors"
+	"math"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
-	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
-func makeMetric(numa, cpu, name, value string) prometheus.Metric {
+func makeMetric(ctx context.Context, numa, cpu, name, value string) prometheus.Metric {
 	m, ok := metrics[name]
 	if !ok {
 		return nil
 	}
-	if !config.MetricSets().Has(m.Set) {
+	if !lib.MetricSets(ctx).Has(m.Set) {
 		return nil
 	}
 
@@ -45,11 +47,7 @@ func (Collector) Metrics() []lib.Metric {
 	for _, m := range metrics {
 		res = append(res, m)
 	}
-	return res
-}
-
-func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
-	lib.DescribeEnabledMetrics(c, ch)
+	return append(res, histograms...)
 }
 
 var (
@@ -57,25 +55,126 @@ var (
 	pmdThreadRe = regexp.MustCompile(`(?m)^pmd thread numa_id (\d+) core_id (\d+):$`)
 	// "  - Used TSC cycles:  997990776491190  ( 99.8 % of total cycles)"
 	pmdPerfStatRe = regexp.MustCompile(`(?m)^\s*([^:]+):\s+(\d+)\s*(.*)$`)
+	// "   499       0            0         157612371830 ..."
+	// "   >         0            >         0            ..."
+	histogramRowRe = regexp.MustCompile(`^\s+(?:(?:\d+|>)\s+\d+\s*)+$`)
+	// "   cycles/it              packets/it             cycles/pkt ..."
+	histogramHeaderRe = regexp.MustCompile(`^\s+cycles/it\s+packets/it\s`)
+	// "------------------------------------------------"
+	histogramSepRe = regexp.MustCompile(`^-+$`)
+	// "   1983                   39.88000               49   ..."
+	histogramAvgRe = regexp.MustCompile(`^\s+(?:[\d\.]+\s*)+$`)
 )
 
-func (Collector) Collect(ch chan<- prometheus.Metric) {
-	buf := appctl.OvsVSwitchd("dpif-netdev/pmd-perf-show")
-	if buf == "" {
+// Bins of the histograms of one pmd thread.
+type pmdHistograms struct {
+	// upper bound of each bin (inclusive), per column
+	walls [][]float64
+	// number of samples in each bin (not cumulative), per column
+	bins [][]uint64
+	// average value per column, if printed
+	avgs []float64
+}
+
+// Parse a histogram row. Each column is a pair of bin upper bound and sample
+// count. The last row has ">" as upper bound.
+func (h *pmdHistograms) parseRow(line string) {
+	fields := strings.Fields(line)
+	if len(fields) != 2*len(histograms) {
 		return
 	}
+	if h.walls == nil {
+		h.walls = make([][]float64, len(histograms))
+		h.bins = make([][]uint64, len(histograms))
+	}
+	for i := range histograms {
+		wall := math.Inf(1)
+		if fields[2*i] != ">" {
+			wall, _ = strconv.ParseFloat(fields[2*i], 64)
+		}
+		count, _ := strconv.ParseUint(fields[2*i+1], 10, 64)
+		h.walls[i] = append(h.walls[i], wall)
+		h.bins[i] = append(h.bins[i], count)
+	}
+}
+
+func (h *pmdHistograms) parseAverages(line string) {
+	fields := strings.Fields(line)
+	if len(fields) != len(histograms) {
+		return
+	}
+	h.avgs = make([]float64, len(histograms))
+	for i, f := range fields {
+		h.avgs[i], _ = strconv.ParseFloat(f, 64)
+	}
+}
+
+// Send the histograms of a pmd thread. OVS does not report the sum of the
+// samples, it is estimated from the printed average if available. The
+// estimation is approximate since the averages are rounded. For the
+// ratioHistograms columns, the sum is always NaN.
+func (h *pmdHistograms) collect(
+	ctx context.Context, ch chan<- prometheus.Metric, numa, cpu string,
+) {
+	for i, m := range histograms {
+		if h.walls == nil || !lib.MetricSets(ctx).Has(m.Set) {
+			continue
+		}
+		buckets := make(map[float64]uint64)
+		var count uint64
+		for b, wall := range h.walls[i] {
+			count += h.bins[i][b]
+			if !math.IsInf(wall, 1) {
+				buckets[wall] = count
+			}
+		}
+		sum := math.NaN()
+		if h.avgs != nil && !ratioHistograms[m.Name] {
+			sum = h.avgs[i] * float64(count)
+		}
+		ch <- prometheus.MustNewConstHistogram(
+			histograms[i].Desc(), count, sum, buckets, numa, cpu)
+	}
+}
+
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	buf, err := appctl.OvsVSwitchd(ctx, "dpif-netdev/pmd-perf-show")
+	var rpcErr *appctl.RPCError
+	if errors.As(err, &rpcErr) {
+		// not a userspace datapath
+		log.Debugf("%s", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
 
 	numa := ""
 	cpu := ""
+	var hist pmdHistograms
+	inAverages := false
 
 	scanner := bufio.NewScanner(strings.NewReader(buf))
 	for scanner.Scan() {
 		line := scanner.Text()
 
 		if numa != "" && cpu != "" {
+			switch {
+			case histogramSepRe.MatchString(line):
+				inAverages = true
+				continue
+			case histogramHeaderRe.MatchString(line):
+				continue
+			case inAverages && histogramAvgRe.MatchString(line):
+				hist.parseAverages(line)
+				inAverages = false
+				continue
+			case histogramRowRe.MatchString(line):
+				hist.parseRow(line)
+				continue
+			}
 			match := pmdPerfStatRe.FindStringSubmatch(line)
 			if match != nil {
-				metric := makeMetric(numa, cpu, match[1], match[2])
+				metric := makeMetric(ctx, numa, cpu, match[1], match[2])
 				if metric != nil {
 					ch <- metric
 				}
@@ -85,8 +184,14 @@ func (Collector) Collect(ch chan<- prometheus.Metric) {
 
 		match := pmdThreadRe.FindStringSubmatch(line)
 		if match != nil {
+			hist.collect(ctx, ch, numa, cpu)
+			hist = pmdHistograms{}
+			inAverages = false
 			numa = match[1]
 			cpu = match[2]
 		}
 	}
+	hist.collect(ctx, ch, numa, cpu)
+
+	return nil
 }
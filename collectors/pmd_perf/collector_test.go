@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package pmd_perf
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl/appctltest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib/libtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+)
+
+const perfStats = `
+Time: 12:02:50.123
+Measurement duration: 10.000 s
+
+pmd thread numa_id 0 core_id 3:
+
+  Iterations:                    7  (80.99 us/it)
+  Rx packets:                   40  (4 Kpps, 2000 cycles/pkt)
+`
+
+const perfHistograms = `
+Histograms
+   cycles/it              packets/it             cycles/pkt             pkts/batch             max vhost qlen         upcalls/it             cycles/upcall
+   499       0            0         3            0         0            1         0            0         10           0         0            499       0
+   716       5            1         2            1000      4            2         5            1         0            1         5            716       0
+   >         2            >         2            >         3            >         0            >         0            >         0            >         0
+-----------------------------------------------------------------------------------------------------------------------------------------------------------------------
+   cycles/it              packets/it             cycles/pkt             pkts/batch             vhost qlen             upcalls/it             cycles/upcall
+   1983                   8.50000                2000                   1.00000                0.00000                1.00000                0
+`
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies appctltest.Replies
+		want    []string
+	}{
+		{
+			name:    "without histograms",
+			replies: appctltest.Replies{"dpif-netdev/pmd-perf-show": perfStats},
+			want: []string{
+				`ovs_pmd_rx_packets{cpu="3",numa="0"} 40`,
+				`ovs_pmd_total_iterations{cpu="3",numa="0"} 7`,
+			},
+		},
+		{
+			name:    "with histograms",
+			replies: appctltest.Replies{"dpif-netdev/pmd-perf-show": perfStats + perfHistograms},
+			want: []string{
+				`ovs_pmd_cycles_per_iteration_bucket{cpu="3",numa="0",le="+Inf"} 7`,
+				`ovs_pmd_cycles_per_iteration_bucket{cpu="3",numa="0",le="499"} 0`,
+				`ovs_pmd_cycles_per_iteration_bucket{cpu="3",numa="0",le="716"} 5`,
+				`ovs_pmd_cycles_per_iteration_count{cpu="3",numa="0"} 7`,
+				`ovs_pmd_cycles_per_iteration_sum{cpu="3",numa="0"} 13881`,
+				`ovs_pmd_cycles_per_packet_bucket{cpu="3",numa="0",le="+Inf"} 7`,
+				`ovs_pmd_cycles_per_packet_bucket{cpu="3",numa="0",le="0"} 0`,
+				`ovs_pmd_cycles_per_packet_bucket{cpu="3",numa="0",le="1000"} 4`,
+				`ovs_pmd_cycles_per_packet_count{cpu="3",numa="0"} 7`,
+				`ovs_pmd_cycles_per_packet_sum{cpu="3",numa="0"} NaN`,
+				`ovs_pmd_cycles_per_upcall_bucket{cpu="3",numa="0",le="+Inf"} 0`,
+				`ovs_pmd_cycles_per_upcall_bucket{cpu="3",numa="0",le="499"} 0`,
+				`ovs_pmd_cycles_per_upcall_bucket{cpu="3",numa="0",le="716"} 0`,
+				`ovs_pmd_cycles_per_upcall_count{cpu="3",numa="0"} 0`,
+				`ovs_pmd_cycles_per_upcall_sum{cpu="3",numa="0"} NaN`,
+				`ovs_pmd_max_vhost_queue_length_bucket{cpu="3",numa="0",le="+Inf"} 10`,
+				`ovs_pmd_max_vhost_queue_length_bucket{cpu="3",numa="0",le="0"} 10`,
+				`ovs_pmd_max_vhost_queue_length_bucket{cpu="3",numa="0",le="1"} 10`,
+				`ovs_pmd_max_vhost_queue_length_count{cpu="3",numa="0"} 10`,
+				`ovs_pmd_max_vhost_queue_length_sum{cpu="3",numa="0"} NaN`,
+				`ovs_pmd_packets_per_batch_bucket{cpu="3",numa="0",le="+Inf"} 5`,
+				`ovs_pmd_packets_per_batch_bucket{cpu="3",numa="0",le="1"} 0`,
+				`ovs_pmd_packets_per_batch_bucket{cpu="3",numa="0",le="2"} 5`,
+				`ovs_pmd_packets_per_batch_count{cpu="3",numa="0"} 5`,
+				`ovs_pmd_packets_per_batch_sum{cpu="3",numa="0"} 5`,
+				`ovs_pmd_packets_per_iteration_bucket{cpu="3",numa="0",le="+Inf"} 7`,
+				`ovs_pmd_packets_per_iteration_bucket{cpu="3",numa="0",le="0"} 3`,
+				`ovs_pmd_packets_per_iteration_bucket{cpu="3",numa="0",le="1"} 5`,
+				`ovs_pmd_packets_per_iteration_count{cpu="3",numa="0"} 7`,
+				`ovs_pmd_packets_per_iteration_sum{cpu="3",numa="0"} 59.5`,
+				`ovs_pmd_rx_packets{cpu="3",numa="0"} 40`,
+				`ovs_pmd_total_iterations{cpu="3",numa="0"} 7`,
+				`ovs_pmd_upcalls_per_iteration_bucket{cpu="3",numa="0",le="+Inf"} 5`,
+				`ovs_pmd_upcalls_per_iteration_bucket{cpu="3",numa="0",le="0"} 0`,
+				`ovs_pmd_upcalls_per_iteration_bucket{cpu="3",numa="0",le="1"} 5`,
+				`ovs_pmd_upcalls_per_iteration_count{cpu="3",numa="0"} 5`,
+				`ovs_pmd_upcalls_per_iteration_sum{cpu="3",numa="0"} 5`,
+			},
+		},
+		{
+			name:    "kernel datapath",
+			replies: appctltest.Replies{},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := appctltest.Start(t, map[string]appctltest.Replies{
+				"ovs-vswitchd": tt.replies,
+			})
+			ctx = lib.WithMetricSets(ctx, config.METRICS_PERF)
+
+			got, err := libtest.Collect(ctx, Collector{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got:\n%s\nwant:\n%s",
+					strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
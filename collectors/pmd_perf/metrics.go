@@ -151,3 +151,69 @@ var metrics = map[string]lib.Metric{
 		Set:         config.METRICS_PERF,
 	},
 }
+
+// Histograms printed when other_config:pmd-perf-metrics=true, in the order of
+// the pmd-perf-show columns.
+var histograms = []lib.Metric{
+	// Histograms
+	//    cycles/it              packets/it             cycles/pkt             ...
+	//    499       0            0         157612371830 0         0            ...
+	{
+		Name:        "ovs_pmd_cycles_per_iteration",
+		Description: "Distribution of the number of CPU cycles per iteration. The sum is estimated from the average printed by OVS.",
+		Histogram:   true,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	{
+		Name:        "ovs_pmd_packets_per_iteration",
+		Description: "Distribution of the number of packets per iteration. The sum is estimated from the average printed by OVS.",
+		Histogram:   true,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	{
+		Name:        "ovs_pmd_cycles_per_packet",
+		Description: "Distribution of the number of CPU cycles per packet. OVS prints a ratio of totals instead of the average of the samples, the sum is not available (NaN).",
+		Histogram:   true,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	{
+		Name:        "ovs_pmd_packets_per_batch",
+		Description: "Distribution of the number of packets per Rx batch. The sum is estimated from the average printed by OVS.",
+		Histogram:   true,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	{
+		Name:        "ovs_pmd_max_vhost_queue_length",
+		Description: "Distribution of the maximum vhost Tx queue fill level per iteration. OVS prints a ratio of totals instead of the average of the samples, the sum is not available (NaN).",
+		Histogram:   true,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	{
+		Name:        "ovs_pmd_upcalls_per_iteration",
+		Description: "Distribution of the number of upcalls per iteration. The sum is estimated from the average printed by OVS.",
+		Histogram:   true,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	{
+		Name:        "ovs_pmd_cycles_per_upcall",
+		Description: "Distribution of the number of CPU cycles per upcall. OVS prints a ratio of totals instead of the average of the samples, the sum is not available (NaN).",
+		Histogram:   true,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+}
+
+// Histograms for which pmd-perf-show prints a ratio of totals (e.g. total
+// cycles divided by total packets) instead of the average of the histogram
+// samples. Their sum cannot be derived from the printed average.
+var ratioHistograms = map[string]bool{
+	"ovs_pmd_cycles_per_packet":      true,
+	"ovs_pmd_max_vhost_queue_length": true,
+	"ovs_pmd_cycles_per_upcall":      true,
+}
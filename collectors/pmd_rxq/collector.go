@@ -5,8 +5,9 @@ package pmd_rxq
 
 import (
 	"bufio"
+	"context"
 	"errors"
-	"io"
+	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -30,10 +31,6 @@ func (Collector) Metrics() []lib.Metric {
 	return []lib.Metric{isolatedMetric, overheadMetric, ctxtSwitchesMetric, nonVolCtxtSwitchesMetric, enabledMetric, usageMetric}
 }
 
-func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
-	lib.DescribeEnabledMetrics(c, ch)
-}
-
 var (
 	// "pmd thread numa_id 0 core_id 39:"
 	pmdThreadRe = regexp.MustCompile(`^pmd thread numa_id (\d+) core_id (\d+):$`)
@@ -46,12 +43,19 @@ var (
 	overheadRe = regexp.MustCompile(`^\s*overhead\s*:\s*([\d\.]+)\s*%$`)
 )
 
-func (Collector) Collect(ch chan<- prometheus.Metric) {
-	stats := getVswitchdPmdStat()
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	// context switches are only an addition to the rxq metrics, still
+	// report them when /proc cannot be read
+	stats, statErr := getVswitchdPmdStat(ctx)
 
-	buf := appctl.OvsVSwitchd("dpif-netdev/pmd-rxq-show")
-	if buf == "" {
-		return
+	buf, err := appctl.OvsVSwitchd(ctx, "dpif-netdev/pmd-rxq-show")
+	var rpcErr *appctl.RPCError
+	if errors.As(err, &rpcErr) {
+		// not a userspace datapath
+		log.Debugf("%s", err)
+		return nil
+	} else if err != nil {
+		return errors.Join(err, statErr)
 	}
 
 	numa := ""
@@ -61,7 +65,7 @@ func (Collector) Collect(ch chan<- prometheus.Metric) {
 	for scanner.Scan() {
 		line := scanner.Text()
 
-		if numa != "" && cpu != "" && config.MetricSets().Has(config.METRICS_PERF) {
+		if numa != "" && cpu != "" && lib.MetricSets(ctx).Has(config.METRICS_PERF) {
 			var val float64
 			var err error
 
@@ -112,13 +116,13 @@ func (Collector) Collect(ch chan<- prometheus.Metric) {
 			if !ok {
 				continue
 			}
-			if config.MetricSets().Has(ctxtSwitchesMetric.Set) {
+			if lib.MetricSets(ctx).Has(ctxtSwitchesMetric.Set) {
 				ch <- prometheus.MustNewConstMetric(
 					ctxtSwitchesMetric.Desc(),
 					ctxtSwitchesMetric.ValueType,
 					float64(stat.ctxSwitches), numa, cpu)
 			}
-			if config.MetricSets().Has(nonVolCtxtSwitchesMetric.Set) {
+			if lib.MetricSets(ctx).Has(nonVolCtxtSwitchesMetric.Set) {
 				ch <- prometheus.MustNewConstMetric(
 					nonVolCtxtSwitchesMetric.Desc(),
 					nonVolCtxtSwitchesMetric.ValueType,
@@ -126,6 +130,8 @@ func (Collector) Collect(ch chan<- prometheus.Metric) {
 			}
 		}
 	}
+
+	return statErr
 }
 
 type pmdstat struct {
@@ -178,24 +184,19 @@ func parseStatus(path string) (pmdstat, error) {
 	return stat, nil
 }
 
-func getVswitchdPmdStat() map[uint64]pmdstat {
-	pidfile := filepath.Join(config.OvsRundir(), "ovs-vswitchd.pid")
-	f, err := os.Open(pidfile)
-	if err != nil {
-		log.Errf("open(%s): %s", pidfile, err)
-		return nil
+func getVswitchdPmdStat(ctx context.Context) (map[uint64]pmdstat, error) {
+	t := lib.TargetFrom(ctx)
+	if t == nil {
+		return nil, fmt.Errorf("no target in context")
 	}
-	defer f.Close()
-	buf, err := io.ReadAll(f)
+	pid, err := appctl.OvsVSwitchdPid(ctx)
 	if err != nil {
-		log.Errf("read(%s): %s", pidfile, err)
-		return nil
+		return nil, err
 	}
-	tasks := filepath.Join(config.OvsProcdir(), strings.TrimSpace(string(buf)), "task")
+	tasks := filepath.Join(t.OvsProcdir, strconv.Itoa(pid), "task")
 	entries, err := os.ReadDir(tasks)
 	if err != nil {
-		log.Errf("readdir(%s): %s", tasks, err)
-		return nil
+		return nil, err
 	}
 
 	stats := make(map[uint64]pmdstat)
@@ -213,5 +214,5 @@ func getVswitchdPmdStat() map[uint64]pmdstat {
 		}
 	}
 
-	return stats
+	return stats, nil
 }
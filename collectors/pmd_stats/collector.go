@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package pmd_stats
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func makeMetric(ctx context.Context, numa, cpu, name, value string) prometheus.Metric {
+	m, ok := metrics[name]
+	if !ok {
+		return nil
+	}
+	if !lib.MetricSets(ctx).Has(m.Set) {
+		return nil
+	}
+
+	val, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Errf("%s: %s: %s", name, value, err)
+		return nil
+	}
+
+	return prometheus.MustNewConstMetric(m.Desc(), m.ValueType, val, numa, cpu)
+}
+
+type Collector struct{}
+
+func (Collector) Name() string {
+	return "pmd-stats"
+}
+
+func (Collector) Metrics() []lib.Metric {
+	var res []lib.Metric
+	for _, m := range metrics {
+		res = append(res, m)
+	}
+	return res
+}
+
+var (
+	// "pmd thread numa_id 0 core_id 39:"
+	pmdThreadRe = regexp.MustCompile(`^pmd thread numa_id (\d+) core_id (\d+):$`)
+	// "main thread:"
+	otherThreadRe = regexp.MustCompile(`^\S.*:$`)
+	// "  idle cycles: 72845013762 (7.30%)"
+	pmdStatRe = regexp.MustCompile(`^\s+([^:]+):\s+([\d\.]+)`)
+)
+
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	buf, err := appctl.OvsVSwitchd(ctx, "dpif-netdev/pmd-stats-show")
+	var rpcErr *appctl.RPCError
+	if errors.As(err, &rpcErr) {
+		// not a userspace datapath
+		log.Debugf("%s", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	numa := ""
+	cpu := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(buf))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := pmdThreadRe.FindStringSubmatch(line); match != nil {
+			numa = match[1]
+			cpu = match[2]
+			continue
+		}
+		if otherThreadRe.MatchString(line) {
+			// ignore the statistics of non-pmd threads
+			numa = ""
+			cpu = ""
+			continue
+		}
+		if numa == "" || cpu == "" {
+			continue
+		}
+		if match := pmdStatRe.FindStringSubmatch(line); match != nil {
+			metric := makeMetric(ctx, numa, cpu, match[1], match[2])
+			if metric != nil {
+				ch <- metric
+			}
+		}
+	}
+
+	return nil
+}
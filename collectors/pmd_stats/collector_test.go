@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package pmd_stats
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl/appctltest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib/libtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+)
+
+const pmdThread = `pmd thread numa_id 0 core_id 3:
+  packets received: 1000
+  packet recirculations: 10
+  avg. datapath passes per packet: 1.01
+  phwol hits: 0
+  mfex opt hits: 0
+  simple match hits: 0
+  emc hits: 900
+  smc hits: 0
+  megaflow hits: 90
+  avg. subtable lookups per megaflow hit: 1.00
+  miss with success upcall: 10
+  miss with failed upcall: 0
+  avg. packets per output batch: 1.50
+  idle cycles: 7000 (70.00%)
+  processing cycles: 3000 (30.00%)
+  avg cycles per packet: 10.00 (10000/1000)
+  avg processing cycles per packet: 3.00 (3000/1000)
+`
+
+const mainThread = `main thread:
+  packets received: 20
+  packet recirculations: 0
+  avg. datapath passes per packet: 1.00
+  emc hits: 0
+  smc hits: 0
+  megaflow hits: 18
+  miss with success upcall: 2
+  miss with failed upcall: 0
+  avg. packets per output batch: 1.00
+`
+
+const pmdStatsShow = pmdThread + mainThread
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name    string
+		sets    config.MetricSet
+		replies appctltest.Replies
+		want    []string
+	}{
+		{
+			name:    "userspace datapath",
+			sets:    config.METRICS_DEFAULT,
+			replies: appctltest.Replies{"dpif-netdev/pmd-stats-show": pmdStatsShow},
+			want: []string{
+				`ovs_pmd_stats_avg_cycles_per_packet{cpu="3",numa="0"} 10`,
+				`ovs_pmd_stats_avg_datapath_passes{cpu="3",numa="0"} 1.01`,
+				`ovs_pmd_stats_avg_output_batch_packets{cpu="3",numa="0"} 1.5`,
+				`ovs_pmd_stats_avg_processing_cycles_per_packet{cpu="3",numa="0"} 3`,
+				`ovs_pmd_stats_avg_subtable_lookups{cpu="3",numa="0"} 1`,
+				`ovs_pmd_stats_emc_hits{cpu="3",numa="0"} 900`,
+				`ovs_pmd_stats_failed_upcalls{cpu="3",numa="0"} 0`,
+				`ovs_pmd_stats_idle_cycles{cpu="3",numa="0"} 7000`,
+				`ovs_pmd_stats_megaflow_hits{cpu="3",numa="0"} 90`,
+				`ovs_pmd_stats_mfex_opt_hits{cpu="3",numa="0"} 0`,
+				`ovs_pmd_stats_phwol_hits{cpu="3",numa="0"} 0`,
+				`ovs_pmd_stats_processing_cycles{cpu="3",numa="0"} 3000`,
+				`ovs_pmd_stats_received_packets{cpu="3",numa="0"} 1000`,
+				`ovs_pmd_stats_recirculations{cpu="3",numa="0"} 10`,
+				`ovs_pmd_stats_simple_match_hits{cpu="3",numa="0"} 0`,
+				`ovs_pmd_stats_smc_hits{cpu="3",numa="0"} 0`,
+				`ovs_pmd_stats_upcalls{cpu="3",numa="0"} 10`,
+			},
+		},
+		{
+			name:    "only main thread",
+			sets:    config.METRICS_DEFAULT,
+			replies: appctltest.Replies{"dpif-netdev/pmd-stats-show": mainThread},
+			want:    nil,
+		},
+		{
+			name:    "kernel datapath",
+			sets:    config.METRICS_DEFAULT,
+			replies: appctltest.Replies{},
+			want:    nil,
+		},
+		{
+			name:    "perf set disabled",
+			sets:    config.METRICS_BASE,
+			replies: appctltest.Replies{"dpif-netdev/pmd-stats-show": pmdStatsShow},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := appctltest.Start(t, map[string]appctltest.Replies{
+				"ovs-vswitchd": tt.replies,
+			})
+			ctx = lib.WithMetricSets(ctx, tt.sets)
+
+			got, err := libtest.Collect(ctx, Collector{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got:\n%s\nwant:\n%s",
+					strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package pmd_stats
+
+import (
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var commonLabels = []string{"numa", "cpu"}
+
+var metrics = map[string]lib.Metric{
+	// pmd thread numa_id 0 core_id 3:
+	//   packets received: 1553492231479
+	//   emc hits: 1208491022134
+	//   avg. subtable lookups per megaflow hit: 1.02
+	//   idle cycles: 72845013762 (7.30%)
+	"packets received": {
+		Name:        "ovs_pmd_stats_received_packets",
+		Description: "Number of packets received.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"packet recirculations": {
+		Name:        "ovs_pmd_stats_recirculations",
+		Description: "Number of packet recirculations.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"avg. datapath passes per packet": {
+		Name:        "ovs_pmd_stats_avg_datapath_passes",
+		Description: "Average number of datapath passes per packet.",
+		ValueType:   prometheus.GaugeValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"phwol hits": {
+		Name:        "ovs_pmd_stats_phwol_hits",
+		Description: "Number of packets that matched a flow offloaded to hardware.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"mfex opt hits": {
+		Name:        "ovs_pmd_stats_mfex_opt_hits",
+		Description: "Number of packets parsed by an optimized miniflow extract implementation.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"simple match hits": {
+		Name:        "ovs_pmd_stats_simple_match_hits",
+		Description: "Number of packets that matched in the simple match cache.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"emc hits": {
+		Name:        "ovs_pmd_stats_emc_hits",
+		Description: "Number of packets that matched in the exact match cache (EMC).",
+		ValueType:   prometheus.CounterValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"smc hits": {
+		Name:        "ovs_pmd_stats_smc_hits",
+		Description: "Number of packets that matched in the signature match cache (SMC).",
+		ValueType:   prometheus.CounterValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"megaflow hits": {
+		Name:        "ovs_pmd_stats_megaflow_hits",
+		Description: "Number of packets that matched in the megaflow classifier.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"avg. subtable lookups per megaflow hit": {
+		Name:        "ovs_pmd_stats_avg_subtable_lookups",
+		Description: "Average number of classifier subtable lookups per megaflow hit.",
+		ValueType:   prometheus.GaugeValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"miss with success upcall": {
+		Name:        "ovs_pmd_stats_upcalls",
+		Description: "Number of cache misses handled by a successful upcall.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"miss with failed upcall": {
+		Name:        "ovs_pmd_stats_failed_upcalls",
+		Description: "Number of cache misses for which the upcall failed.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"avg. packets per output batch": {
+		Name:        "ovs_pmd_stats_avg_output_batch_packets",
+		Description: "Average number of packets per output batch.",
+		ValueType:   prometheus.GaugeValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"idle cycles": {
+		Name:        "ovs_pmd_stats_idle_cycles",
+		Description: "Number of CPU cycles spent polling without receiving packets.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"processing cycles": {
+		Name:        "ovs_pmd_stats_processing_cycles",
+		Description: "Number of CPU cycles spent processing packets.",
+		ValueType:   prometheus.CounterValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"avg cycles per packet": {
+		Name:        "ovs_pmd_stats_avg_cycles_per_packet",
+		Description: "Average number of CPU cycles per packet, including idle cycles.",
+		ValueType:   prometheus.GaugeValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+	"avg processing cycles per packet": {
+		Name:        "ovs_pmd_stats_avg_processing_cycles_per_packet",
+		Description: "Average number of CPU cycles spent processing each packet.",
+		ValueType:   prometheus.GaugeValue,
+		Labels:      commonLabels,
+		Set:         config.METRICS_PERF,
+	},
+}
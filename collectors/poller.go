@@ -0,0 +1,190 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package collectors
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	pollAgeDesc = prometheus.NewDesc(
+		"openstack_network_exporter_poll_age_seconds",
+		"Number of seconds elapsed since the served metrics of a collector were gathered.",
+		[]string{"collector"}, nil)
+	pollIntervalDesc = prometheus.NewDesc(
+		"openstack_network_exporter_poll_interval_seconds",
+		"Background polling interval of a collector in seconds.",
+		[]string{"collector"}, nil)
+)
+
+// A snapshot of all metrics returned by a collector during one poll.
+type snapshot struct {
+	metrics   []prometheus.Metric
+	timestamp time.Time
+	duration  time.Duration
+	err       error
+}
+
+type poller struct {
+	collector lib.Collector
+	target    *lib.Target
+	sets      config.MetricSet
+	interval  time.Duration
+	// metric set of each metric indexed by its description
+	descSets map[string]config.MetricSet
+	lock     sync.RWMutex
+	last     snapshot
+}
+
+func (p *poller) poll(ctx context.Context) {
+	// do not let a poll overlap with the next one
+	ctx, cancel := context.WithTimeout(ctx, p.interval)
+	defer cancel()
+	ctx = lib.WithMetricSets(p.target.Bind(ctx), p.sets)
+
+	snap := gather(ctx, p.collector)
+
+	log.Debugf("%T: polled %d metrics in %s",
+		p.collector, len(snap.metrics), snap.duration)
+
+	// swap the whole snapshot at once so that scrapes never see
+	// a partial result
+	p.lock.Lock()
+	p.last = snap
+	p.lock.Unlock()
+}
+
+func (p *poller) run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	p.poll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.poll(ctx)
+		}
+	}
+}
+
+func (p *poller) snapshot() snapshot {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.last
+}
+
+// Poller runs collectors against a target in the background and serves the
+// last gathered snapshot of their metrics instead of querying OVS/OVN on every
+// scrape.
+type Poller struct {
+	pollers []*poller
+	target  *lib.Target
+	sets    config.MetricSet
+}
+
+// Create a poller that gathers the metrics of the specified sets. Collectors
+// are polled at their interval in intervals or at the default interval.
+func NewPoller(
+	collectors []lib.Collector, target *lib.Target, sets config.MetricSet,
+	interval time.Duration, intervals map[string]time.Duration,
+) *Poller {
+	p := &Poller{target: target, sets: sets}
+	for _, c := range collectors {
+		descSets := make(map[string]config.MetricSet)
+		for _, m := range c.Metrics() {
+			descSets[m.Desc().String()] = m.Set
+		}
+		d, ok := intervals[c.Name()]
+		if !ok || d == 0 {
+			d = interval
+		}
+		p.pollers = append(p.pollers, &poller{
+			collector: c,
+			target:    target,
+			sets:      sets,
+			interval:  d,
+			descSets:  descSets,
+		})
+	}
+	return p
+}
+
+// Start one background goroutine per collector. They are stopped when the
+// context is cancelled.
+func (p *Poller) Start(ctx context.Context) {
+	for _, c := range p.pollers {
+		log.Infof("polling %T every %s", c.collector, c.interval)
+		go c.run(ctx)
+	}
+}
+
+func (p *Poller) Describe(ch chan<- *prometheus.Desc) {
+	describeScrapeMetrics(ch)
+	ch <- pollAgeDesc
+	ch <- pollIntervalDesc
+	for _, c := range p.pollers {
+		lib.DescribeMetrics(c.collector, p.sets, ch)
+	}
+}
+
+func (p *Poller) Collect(ch chan<- prometheus.Metric) {
+	p.collect(ch, Filter{MetricSets: p.sets})
+}
+
+// Return a prometheus.Collector that serves the last snapshots of the
+// collectors selected by f, restricted to the selected metric sets. The
+// returned collector is unchecked.
+func (p *Poller) Select(f Filter) prometheus.Collector {
+	return &selection{poller: p, filter: f}
+}
+
+type selection struct {
+	poller *Poller
+	filter Filter
+}
+
+func (s *selection) Describe(ch chan<- *prometheus.Desc) {}
+
+func (s *selection) Collect(ch chan<- prometheus.Metric) {
+	s.poller.collect(ch, s.filter)
+}
+
+func (p *Poller) collect(ch chan<- prometheus.Metric, f Filter) {
+	now := time.Now()
+
+	for _, c := range p.pollers {
+		if !f.selects(c.collector.Name()) {
+			continue
+		}
+		snap := c.snapshot()
+		if snap.timestamp.IsZero() {
+			// first poll not completed yet
+			continue
+		}
+		for _, m := range snap.metrics {
+			if set, ok := c.descSets[m.Desc().String()]; ok && !f.MetricSets.Has(set) {
+				continue
+			}
+			ch <- m
+		}
+		collectScrapeMetrics(ch, c.collector.Name(), snap.duration, snap.err)
+		ch <- prometheus.MustNewConstMetric(pollAgeDesc,
+			prometheus.GaugeValue, now.Sub(snap.timestamp).Seconds(),
+			c.collector.Name())
+		ch <- prometheus.MustNewConstMetric(pollIntervalDesc,
+			prometheus.GaugeValue, c.interval.Seconds(),
+			c.collector.Name())
+	}
+
+	collectHealthMetrics(ch, p.target)
+}
@@ -0,0 +1,260 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package collectors
+
+import (
+	"context"
+	"time"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	scrapeSuccessDesc = prometheus.NewDesc(
+		"openstack_network_exporter_scrape_collector_success",
+		"Whether a collector succeeded to gather all its metrics.",
+		[]string{"collector"}, nil)
+	scrapeDurationDesc = prometheus.NewDesc(
+		"openstack_network_exporter_scrape_collector_duration_seconds",
+		"Duration of a collector scrape in seconds.",
+		[]string{"collector"}, nil)
+	endpointUpDesc = prometheus.NewDesc(
+		"openstack_network_exporter_endpoint_up",
+		"Whether an OVS/OVN endpoint could be reached during the last attempt. "+
+			"Endpoints are daemon unixctl sockets, the OVSDB db.sock, "+
+			"bridge OpenFlow management sockets and OVN databases.",
+		[]string{"endpoint"}, nil)
+	ovsdbConnectedDesc = prometheus.NewDesc(
+		"openstack_network_exporter_ovsdb_connected",
+		"Whether the exporter is currently connected to the OVSDB db.sock.",
+		nil, nil)
+	ovsdbDisconnectsDesc = prometheus.NewDesc(
+		"openstack_network_exporter_ovsdb_disconnects_total",
+		"Number of times the connection to the OVSDB db.sock was lost.",
+		nil, nil)
+	ovsdbReconnectsDesc = prometheus.NewDesc(
+		"openstack_network_exporter_ovsdb_reconnects_total",
+		"Number of times the connection to the OVSDB db.sock was re-established.",
+		nil, nil)
+)
+
+// Run a collector and return how long it took along with any error it
+// reported. Errors are logged here so that collectors do not have to.
+func collect(
+	ctx context.Context, c lib.Collector, ch chan<- prometheus.Metric,
+) (time.Duration, error) {
+	start := time.Now()
+	err := c.Collect(ctx, ch)
+	duration := time.Since(start)
+	if err != nil {
+		if t := lib.TargetFrom(ctx); t != nil && t.Name != "" {
+			log.Errf("%s: %s: %s", t.Name, c.Name(), err)
+		} else {
+			log.Errf("%s: %s", c.Name(), err)
+		}
+	}
+	return duration, err
+}
+
+// Run a collector and buffer all the metrics it returned.
+func gather(ctx context.Context, c lib.Collector) snapshot {
+	ch := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	var metrics []prometheus.Metric
+
+	go func() {
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+	start := time.Now()
+	duration, err := collect(ctx, c, ch)
+	close(ch)
+	<-done
+
+	return snapshot{
+		metrics:   metrics,
+		timestamp: start,
+		duration:  duration,
+		err:       err,
+	}
+}
+
+func describeScrapeMetrics(ch chan<- *prometheus.Desc) {
+	ch <- scrapeSuccessDesc
+	ch <- scrapeDurationDesc
+	ch <- endpointUpDesc
+	ch <- ovsdbConnectedDesc
+	ch <- ovsdbDisconnectsDesc
+	ch <- ovsdbReconnectsDesc
+}
+
+func collectScrapeMetrics(
+	ch chan<- prometheus.Metric, name string, duration time.Duration, err error,
+) {
+	success := 1.0
+	if err != nil {
+		success = 0
+	}
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc,
+		prometheus.GaugeValue, success, name)
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc,
+		prometheus.GaugeValue, duration.Seconds(), name)
+}
+
+// Report the reachability of all OVS/OVN endpoints of a target and the health
+// of its OVSDB connection.
+func collectHealthMetrics(ch chan<- prometheus.Metric, t *lib.Target) {
+	endpoints := t.Appctl.Reachable()
+	if ok, known := t.Ovsdb.Reachable(); known {
+		endpoints["db.sock"] = ok
+	}
+	for sock, ok := range t.Openflow.Reachable() {
+		endpoints[sock] = ok
+	}
+	for _, db := range []*ovsdb.Client{t.Nbdb, t.CurrentSbdb()} {
+		if db == nil {
+			continue
+		}
+		if ok, known := db.Reachable(); known {
+			endpoints[db.Name()] = ok
+		}
+	}
+	for endpoint, ok := range endpoints {
+		up := 0.0
+		if ok {
+			up = 1
+		}
+		ch <- prometheus.MustNewConstMetric(endpointUpDesc,
+			prometheus.GaugeValue, up, endpoint)
+	}
+
+	status := t.Ovsdb.GetStatus()
+	if !status.Initialized {
+		return
+	}
+	connected := 0.0
+	if status.Connected {
+		connected = 1
+	}
+	ch <- prometheus.MustNewConstMetric(ovsdbConnectedDesc,
+		prometheus.GaugeValue, connected)
+	ch <- prometheus.MustNewConstMetric(ovsdbDisconnectsDesc,
+		prometheus.CounterValue, float64(status.Disconnects))
+	ch <- prometheus.MustNewConstMetric(ovsdbReconnectsDesc,
+		prometheus.CounterValue, float64(status.Reconnects))
+}
+
+// Exporter runs all collectors concurrently against a target on every scrape
+// and reports their success and duration.
+type Exporter struct {
+	collectors []lib.Collector
+	target     *lib.Target
+	sets       config.MetricSet
+	timeout    time.Duration
+}
+
+// Create an exporter that gathers the metrics of the specified sets. The
+// timeout applies when the exporter is used as a prometheus.Collector.
+func NewExporter(
+	collectors []lib.Collector, target *lib.Target,
+	sets config.MetricSet, timeout time.Duration,
+) *Exporter {
+	return &Exporter{
+		collectors: collectors,
+		target:     target,
+		sets:       sets,
+		timeout:    timeout,
+	}
+}
+
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	describeScrapeMetrics(ch)
+	for _, c := range e.collectors {
+		lib.DescribeMetrics(c, e.sets, ch)
+	}
+}
+
+// Run all collectors with the default scrape timeout.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	e.collect(lib.WithMetricSets(ctx, e.sets), ch)
+}
+
+// Return a prometheus.Collector that runs the collectors and metric sets
+// selected by f with the given context. It is meant to be registered in
+// a per-scrape registry so that the scraper deadline is propagated to all
+// OVS/OVN calls. The returned collector is unchecked, metric descriptions are
+// only validated when registering the Exporter itself.
+func (e *Exporter) Select(ctx context.Context, f Filter) prometheus.Collector {
+	selected := &Exporter{target: e.target, sets: e.sets, timeout: e.timeout}
+	for _, c := range e.collectors {
+		if f.selects(c.Name()) {
+			selected.collectors = append(selected.collectors, c)
+		}
+	}
+	return &scrape{
+		exporter: selected,
+		ctx:      lib.WithMetricSets(ctx, f.MetricSets),
+	}
+}
+
+type scrape struct {
+	exporter *Exporter
+	ctx      context.Context
+}
+
+func (s *scrape) Describe(ch chan<- *prometheus.Desc) {}
+
+func (s *scrape) Collect(ch chan<- prometheus.Metric) {
+	s.exporter.collect(s.ctx, ch)
+}
+
+// Run all collectors concurrently and send their metrics to ch. When the
+// context is done, metrics of the collectors that completed are sent and the
+// others are reported as failed. Their results are discarded once they
+// eventually return.
+func (e *Exporter) collect(ctx context.Context, ch chan<- prometheus.Metric) {
+	type result struct {
+		name string
+		snap snapshot
+	}
+	ctx = e.target.Bind(ctx)
+	start := time.Now()
+	// buffered so that late collectors never block
+	results := make(chan result, len(e.collectors))
+	pending := make(map[string]bool)
+
+	for _, c := range e.collectors {
+		pending[c.Name()] = true
+		go func(c lib.Collector) {
+			results <- result{name: c.Name(), snap: gather(ctx, c)}
+		}(c)
+	}
+
+	for len(pending) > 0 {
+		select {
+		case r := <-results:
+			delete(pending, r.name)
+			for _, m := range r.snap.metrics {
+				ch <- m
+			}
+			collectScrapeMetrics(ch, r.name, r.snap.duration, r.snap.err)
+		case <-ctx.Done():
+			for name := range pending {
+				log.Errf("%s: %s", name, ctx.Err())
+				collectScrapeMetrics(ch, name, time.Since(start), ctx.Err())
+				delete(pending, name)
+			}
+		}
+	}
+
+	collectHealthMetrics(ch, e.target)
+}
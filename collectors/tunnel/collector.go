@@ -0,0 +1,137 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Collector struct{}
+
+func (Collector) Name() string {
+	return "tunnel"
+}
+
+func (Collector) Metrics() []lib.Metric {
+	return []lib.Metric{egressCarrierMetric, neighborsMetric, routesMetric}
+}
+
+// Interface types that are implemented as tunnels by OVS.
+var tunnelTypes = map[string]bool{
+	"bareudp":   true,
+	"erspan":    true,
+	"geneve":    true,
+	"gre":       true,
+	"gtpu":      true,
+	"ip6erspan": true,
+	"ip6gre":    true,
+	"lisp":      true,
+	"srv6":      true,
+	"stt":       true,
+	"vxlan":     true,
+}
+
+var (
+	// "10.0.0.2                                      aa:bb:cc:dd:ee:ff   br-ex"
+	neighRe = regexp.MustCompile(`^\S+\s+[0-9a-fA-F:]{17}\s+\S+`)
+	// "Cached: 10.0.0.0/24 dev br-ex SRC 10.0.0.1"
+	routeRe = regexp.MustCompile(`^(Cached|User): `)
+)
+
+func carrierValue(status map[string]string) float64 {
+	switch status["tunnel_egress_iface_carrier"] {
+	case "up":
+		return 1
+	case "down":
+		return 0
+	}
+	return -1
+}
+
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	var bridges []ovs.Bridge
+	var ifaces []ovs.Interface
+
+	err := ovsdb.List(ctx, &bridges)
+	if err != nil {
+		return fmt.Errorf("db.List(Bridge): %w", err)
+	}
+	err = ovsdb.List(ctx, &ifaces)
+	if err != nil {
+		return fmt.Errorf("db.List(Interface): %w", err)
+	}
+
+	if lib.MetricSets(ctx).Has(egressCarrierMetric.Set) {
+		for _, i := range ifaces {
+			if !tunnelTypes[i.Type] {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(
+				egressCarrierMetric.Desc(), egressCarrierMetric.ValueType,
+				carrierValue(i.Status),
+				i.Name, i.Type, i.Options["remote_ip"], i.Options["local_ip"],
+				i.Options["key"], i.Status["tunnel_egress_iface"])
+		}
+	}
+
+	if !lib.MetricSets(ctx).Has(config.METRICS_PERF) {
+		return nil
+	}
+
+	// the tunnel neighbor cache and routing table are only used by the
+	// userspace datapath
+	userspace := false
+	for _, br := range bridges {
+		if br.DatapathType == "netdev" {
+			userspace = true
+			break
+		}
+	}
+	if !userspace {
+		return nil
+	}
+
+	buf, err := appctl.OvsVSwitchd(ctx, "tnl/neigh/show")
+	if err != nil {
+		return err
+	}
+	neighbors := 0
+	scanner := bufio.NewScanner(strings.NewReader(buf))
+	for scanner.Scan() {
+		if neighRe.MatchString(scanner.Text()) {
+			neighbors++
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(
+		neighborsMetric.Desc(), neighborsMetric.ValueType, float64(neighbors))
+
+	buf, err = appctl.OvsVSwitchd(ctx, "ovs/route/show")
+	if err != nil {
+		return err
+	}
+	routes := map[string]int{"cached": 0, "user": 0}
+	scanner = bufio.NewScanner(strings.NewReader(buf))
+	for scanner.Scan() {
+		if m := routeRe.FindStringSubmatch(scanner.Text()); m != nil {
+			routes[strings.ToLower(m[1])]++
+		}
+	}
+	for origin, count := range routes {
+		ch <- prometheus.MustNewConstMetric(
+			routesMetric.Desc(), routesMetric.ValueType, float64(count), origin)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package tunnel
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl/appctltest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib/libtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovsdbtest"
+	"github.com/ovn-org/libovsdb/model"
+)
+
+// br-int with two geneve tunnels and a patch port
+func tunnelRows(datapathType string) []model.Model {
+	return []model.Model{
+		&ovs.OpenvSwitch{UUID: "ovs", Bridges: []string{"brint"}},
+		&ovs.Bridge{
+			UUID: "brint", Name: "br-int", DatapathType: datapathType,
+			Ports: []string{"ovn0", "ovn1", "patch"},
+		},
+		&ovs.Port{UUID: "ovn0", Name: "ovn-1a2b3c-0", Interfaces: []string{"ovn0if"}},
+		&ovs.Port{UUID: "ovn1", Name: "ovn-4d5e6f-0", Interfaces: []string{"ovn1if"}},
+		&ovs.Port{UUID: "patch", Name: "patch-br-int-to-br-ex", Interfaces: []string{"patchif"}},
+		&ovs.Interface{
+			UUID: "ovn0if", Name: "ovn-1a2b3c-0", Type: "geneve",
+			Options: map[string]string{
+				"csum": "true", "key": "flow", "local_ip": "10.0.0.1", "remote_ip": "10.0.0.2",
+			},
+			Status: map[string]string{
+				"tunnel_egress_iface": "br-ex", "tunnel_egress_iface_carrier": "up",
+			},
+		},
+		&ovs.Interface{
+			UUID: "ovn1if", Name: "ovn-4d5e6f-0", Type: "geneve",
+			Options: map[string]string{
+				"key": "flow", "local_ip": "10.0.0.1", "remote_ip": "10.0.0.3",
+			},
+		},
+		&ovs.Interface{
+			UUID: "patchif", Name: "patch-br-int-to-br-ex", Type: "patch",
+			Options: map[string]string{"peer": "patch-br-ex-to-br-int"},
+		},
+	}
+}
+
+const neighShow = `IP                                            MAC                 Bridge
+==========================================================================
+10.0.0.2                                      aa:bb:cc:dd:ee:02   br-ex
+10.0.0.3                                      aa:bb:cc:dd:ee:03   br-ex
+fe80::1                                       aa:bb:cc:dd:ee:01   br-ex
+`
+
+const routeShow = `Route Table:
+Cached: 10.0.0.0/24 dev br-ex SRC 10.0.0.1
+Cached: 127.0.0.1/32 dev lo SRC 127.0.0.1 local
+User: 10.1.0.0/16 dev br-ex GW 10.0.0.254 SRC 10.0.0.1
+`
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name    string
+		sets    config.MetricSet
+		rows    []model.Model
+		replies appctltest.Replies
+		want    []string
+	}{
+		{
+			name: "userspace datapath",
+			sets: config.METRICS_DEFAULT,
+			rows: tunnelRows("netdev"),
+			replies: appctltest.Replies{
+				"tnl/neigh/show": neighShow,
+				"ovs/route/show": routeShow,
+			},
+			want: []string{
+				`ovs_tunnel_egress_carrier{egress_interface="",interface="ovn-4d5e6f-0",key="flow",local_ip="10.0.0.1",remote_ip="10.0.0.3",type="geneve"} -1`,
+				`ovs_tunnel_egress_carrier{egress_interface="br-ex",interface="ovn-1a2b3c-0",key="flow",local_ip="10.0.0.1",remote_ip="10.0.0.2",type="geneve"} 1`,
+				`ovs_tunnel_neighbor_entries 3`,
+				`ovs_tunnel_route_entries{origin="cached"} 2`,
+				`ovs_tunnel_route_entries{origin="user"} 1`,
+			},
+		},
+		{
+			name:    "no netdev bridge",
+			sets:    config.METRICS_DEFAULT,
+			rows:    tunnelRows("system"),
+			replies: appctltest.Replies{},
+			want: []string{
+				`ovs_tunnel_egress_carrier{egress_interface="",interface="ovn-4d5e6f-0",key="flow",local_ip="10.0.0.1",remote_ip="10.0.0.3",type="geneve"} -1`,
+				`ovs_tunnel_egress_carrier{egress_interface="br-ex",interface="ovn-1a2b3c-0",key="flow",local_ip="10.0.0.1",remote_ip="10.0.0.2",type="geneve"} 1`,
+			},
+		},
+		{
+			name:    "no bridges",
+			sets:    config.METRICS_DEFAULT,
+			rows:    nil,
+			replies: appctltest.Replies{},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := appctltest.Start(t, map[string]appctltest.Replies{
+				"ovs-vswitchd": tt.replies,
+			})
+			ctx = ovsdb.WithClient(ctx, ovsdbtest.NewClient(t, tt.rows...))
+			ctx = lib.WithMetricSets(ctx, tt.sets)
+
+			got, err := libtest.Collect(ctx, Collector{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got:\n%s\nwant:\n%s",
+					strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package tunnel
+
+import (
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var egressCarrierMetric = lib.Metric{
+	Name:        "ovs_tunnel_egress_carrier",
+	Description: "The carrier of the interface used to reach the tunnel remote IP. Possible values are: up(1), down(0) or unknown(-1).",
+	Labels:      []string{"interface", "type", "remote_ip", "local_ip", "key", "egress_interface"},
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_BASE,
+}
+
+var neighborsMetric = lib.Metric{
+	Name:        "ovs_tunnel_neighbor_entries",
+	Description: "The number of entries in the tunnel neighbor (ARP/ND) cache of the userspace datapath.",
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_PERF,
+}
+
+var routesMetric = lib.Metric{
+	Name:        "ovs_tunnel_route_entries",
+	Description: "The number of entries in the routing table used by the userspace datapath to send tunneled packets. The origin label is cached (from the kernel) or user (added with ovs/route/add).",
+	Labels:      []string{"origin"},
+	ValueType:   prometheus.GaugeValue,
+	Set:         config.METRICS_PERF,
+}
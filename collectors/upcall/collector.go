@@ -0,0 +1,108 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package upcall
+
+import (
+	"bufio"
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Collector struct{}
+
+func (Collector) Name() string {
+	return "upcall"
+}
+
+func (Collector) Metrics() []lib.Metric {
+	return []lib.Metric{
+		flowsMetric, flowsAvgMetric, flowsMaxMetric, flowLimitMetric,
+		offloadedFlowsMetric, dumpDurationMetric, ufidEnabledMetric,
+		revalidatorKeysMetric,
+	}
+}
+
+var (
+	// "system@ovs-system:"
+	datapathRe = regexp.MustCompile(`^([\w-]+)@([\w-]+):$`)
+	// "  flows         : (current 42) (avg 40) (max 120) (limit 200000)"
+	flowsRe = regexp.MustCompile(
+		`^\s*flows\s*:\s*\(current (\d+)\)\s*\(avg (\d+)\)\s*\(max (\d+)\)\s*\(limit (\d+)\)$`)
+	// "  offloaded flows : 0"
+	offloadedRe = regexp.MustCompile(`^\s*offloaded flows\s*:\s*(\d+)$`)
+	// "  dump duration : 2ms"
+	dumpDurationRe = regexp.MustCompile(`^\s*dump duration\s*:\s*(\d+)ms$`)
+	// "  ufid enabled : true"
+	ufidRe = regexp.MustCompile(`^\s*ufid enabled\s*:\s*(true|false)$`)
+	// "  7: (keys 10)"
+	keysRe = regexp.MustCompile(`^\s*(\d+): \(keys (\d+)\)$`)
+)
+
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if !lib.MetricSets(ctx).Has(config.METRICS_PERF) {
+		return nil
+	}
+
+	buf, err := appctl.OvsVSwitchd(ctx, "upcall/show")
+	if err != nil {
+		return err
+	}
+
+	dptype := ""
+	dpname := ""
+
+	metric := func(m *lib.Metric, val float64, labels ...string) {
+		labels = append([]string{dptype, dpname}, labels...)
+		ch <- prometheus.MustNewConstMetric(m.Desc(), m.ValueType, val, labels...)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(buf))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := datapathRe.FindStringSubmatch(line); m != nil {
+			dptype = m[1]
+			dpname = m[2]
+			continue
+		}
+		if dptype == "" || dpname == "" {
+			continue
+		}
+
+		if m := flowsRe.FindStringSubmatch(line); m != nil {
+			val, _ := strconv.ParseFloat(m[1], 64)
+			metric(&flowsMetric, val)
+			val, _ = strconv.ParseFloat(m[2], 64)
+			metric(&flowsAvgMetric, val)
+			val, _ = strconv.ParseFloat(m[3], 64)
+			metric(&flowsMaxMetric, val)
+			val, _ = strconv.ParseFloat(m[4], 64)
+			metric(&flowLimitMetric, val)
+		} else if m := offloadedRe.FindStringSubmatch(line); m != nil {
+			val, _ := strconv.ParseFloat(m[1], 64)
+			metric(&offloadedFlowsMetric, val)
+		} else if m := dumpDurationRe.FindStringSubmatch(line); m != nil {
+			val, _ := strconv.ParseFloat(m[1], 64)
+			metric(&dumpDurationMetric, val/1000)
+		} else if m := ufidRe.FindStringSubmatch(line); m != nil {
+			val := 0.0
+			if m[1] == "true" {
+				val = 1
+			}
+			metric(&ufidEnabledMetric, val)
+		} else if m := keysRe.FindStringSubmatch(line); m != nil {
+			val, _ := strconv.ParseFloat(m[2], 64)
+			metric(&revalidatorKeysMetric, val, m[1])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package upcall
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/appctl/appctltest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib/libtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+)
+
+const upcallShow = `system@ovs-system:
+  flows         : (current 42) (avg 40) (max 120) (limit 200000)
+  offloaded flows : 3
+  dump duration : 2ms
+  ufid enabled : true
+
+  12: (keys 20)
+  13: (keys 22)
+`
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name    string
+		sets    config.MetricSet
+		replies appctltest.Replies
+		want    []string
+	}{
+		{
+			name:    "kernel datapath",
+			sets:    config.METRICS_DEFAULT,
+			replies: appctltest.Replies{"upcall/show": upcallShow},
+			want: []string{
+				`ovs_upcall_dump_duration_seconds{name="ovs-system",type="system"} 0.002`,
+				`ovs_upcall_flow_limit{name="ovs-system",type="system"} 200000`,
+				`ovs_upcall_flows_average{name="ovs-system",type="system"} 40`,
+				`ovs_upcall_flows_max{name="ovs-system",type="system"} 120`,
+				`ovs_upcall_flows{name="ovs-system",type="system"} 42`,
+				`ovs_upcall_offloaded_flows{name="ovs-system",type="system"} 3`,
+				`ovs_upcall_revalidator_keys{name="ovs-system",revalidator="12",type="system"} 20`,
+				`ovs_upcall_revalidator_keys{name="ovs-system",revalidator="13",type="system"} 22`,
+				`ovs_upcall_ufid_enabled{name="ovs-system",type="system"} 1`,
+			},
+		},
+		{
+			name: "ufid disabled",
+			sets: config.METRICS_DEFAULT,
+			replies: appctltest.Replies{
+				"upcall/show": "netdev@ovs-netdev:\n" +
+					"  flows         : (current 0) (avg 0) (max 0) (limit 10000)\n" +
+					"  offloaded flows : 0\n" +
+					"  dump duration : 0ms\n" +
+					"  ufid enabled : false\n",
+			},
+			want: []string{
+				`ovs_upcall_dump_duration_seconds{name="ovs-netdev",type="netdev"} 0`,
+				`ovs_upcall_flow_limit{name="ovs-netdev",type="netdev"} 10000`,
+				`ovs_upcall_flows_average{name="ovs-netdev",type="netdev"} 0`,
+				`ovs_upcall_flows_max{name="ovs-netdev",type="netdev"} 0`,
+				`ovs_upcall_flows{name="ovs-netdev",type="netdev"} 0`,
+				`ovs_upcall_offloaded_flows{name="ovs-netdev",type="netdev"} 0`,
+				`ovs_upcall_ufid_enabled{name="ovs-netdev",type="netdev"} 0`,
+			},
+		},
+		{
+			name:    "perf set disabled",
+			sets:    config.METRICS_BASE,
+			replies: appctltest.Replies{"upcall/show": upcallShow},
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := appctltest.Start(t, map[string]appctltest.Replies{
+				"ovs-vswitchd": tt.replies,
+			})
+			ctx = lib.WithMetricSets(ctx, tt.sets)
+
+			got, err := libtest.Collect(ctx, Collector{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got:\n%s\nwant:\n%s",
+					strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
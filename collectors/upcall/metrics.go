@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package upcall
+
+import (
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var commonLabels = []string{"type", "name"}
+
+var flowsMetric = lib.Metric{
+	Name:        "ovs_upcall_flows",
+	Description: "The current number of datapath flows.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      commonLabels,
+	Set:         config.METRICS_PERF,
+}
+
+var flowsAvgMetric = lib.Metric{
+	Name:        "ovs_upcall_flows_average",
+	Description: "The average number of datapath flows over the recent revalidation rounds.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      commonLabels,
+	Set:         config.METRICS_PERF,
+}
+
+var flowsMaxMetric = lib.Metric{
+	Name:        "ovs_upcall_flows_max",
+	Description: "The maximum number of datapath flows seen since the last flow limit update.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      commonLabels,
+	Set:         config.METRICS_PERF,
+}
+
+var flowLimitMetric = lib.Metric{
+	Name:        "ovs_upcall_flow_limit",
+	Description: "The dynamic datapath flow limit. It is reduced when revalidation takes too long.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      commonLabels,
+	Set:         config.METRICS_PERF,
+}
+
+var offloadedFlowsMetric = lib.Metric{
+	Name:        "ovs_upcall_offloaded_flows",
+	Description: "The number of datapath flows offloaded to hardware.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      commonLabels,
+	Set:         config.METRICS_PERF,
+}
+
+var dumpDurationMetric = lib.Metric{
+	Name:        "ovs_upcall_dump_duration_seconds",
+	Description: "The duration of the last datapath flows dump by the revalidators.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      commonLabels,
+	Set:         config.METRICS_PERF,
+}
+
+var ufidEnabledMetric = lib.Metric{
+	Name:        "ovs_upcall_ufid_enabled",
+	Description: "Are datapath flows identified by unique flow identifiers (UFID).",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      commonLabels,
+	Set:         config.METRICS_PERF,
+}
+
+var revalidatorKeysMetric = lib.Metric{
+	Name:        "ovs_upcall_revalidator_keys",
+	Description: "The number of datapath flow keys handled by a revalidator thread.",
+	ValueType:   prometheus.GaugeValue,
+	Labels:      append(commonLabels, "revalidator"),
+	Set:         config.METRICS_PERF,
+}
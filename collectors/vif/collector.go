@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package vif
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Collector struct{}
+
+func (Collector) Name() string {
+	return "vif"
+}
+
+func (Collector) Metrics() []lib.Metric {
+	return []lib.Metric{
+		receivedPacketsMetric, receivedBytesMetric,
+		deliveredPacketsMetric, deliveredBytesMetric,
+	}
+}
+
+// Return the interfaces of the integration bridge that are bound to an OVN
+// logical port, indexed by openflow port number.
+func getVifs(ctx context.Context, intBridge string) (map[uint16]*ovs.Interface, error) {
+	var bridges []ovs.Bridge
+	var ports []ovs.Port
+	var ifaces []ovs.Interface
+
+	err := ovsdb.List(ctx, &bridges)
+	if err != nil {
+		return nil, fmt.Errorf("db.List(Bridge): %w", err)
+	}
+	err = ovsdb.List(ctx, &ports)
+	if err != nil {
+		return nil, fmt.Errorf("db.List(Port): %w", err)
+	}
+	err = ovsdb.List(ctx, &ifaces)
+	if err != nil {
+		return nil, fmt.Errorf("db.List(Interface): %w", err)
+	}
+
+	// openflow port numbers are only unique within a bridge
+	intPorts := make(map[string]bool)
+	for _, br := range bridges {
+		if br.Name == intBridge {
+			for _, p := range br.Ports {
+				intPorts[p] = true
+			}
+		}
+	}
+	intIfaces := make(map[string]bool)
+	for _, p := range ports {
+		if intPorts[p.UUID] {
+			for _, i := range p.Interfaces {
+				intIfaces[i] = true
+			}
+		}
+	}
+
+	vifs := make(map[uint16]*ovs.Interface)
+	for i := range ifaces {
+		iface := &ifaces[i]
+		if !intIfaces[iface.UUID] || iface.Ofport == nil || *iface.Ofport <= 0 {
+			continue
+		}
+		if iface.ExternalIDs["iface-id"] == "" {
+			continue
+		}
+		vifs[uint16(*iface.Ofport)] = iface
+	}
+
+	return vifs, nil
+}
+
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if !lib.MetricSets(ctx).Has(config.METRICS_COUNTERS) {
+		return nil
+	}
+
+	t := lib.TargetFrom(ctx)
+	if t == nil {
+		return errors.New("no target in context")
+	}
+	intBridge := t.IntBridge()
+
+	vifs, err := getVifs(ctx, intBridge)
+	if err != nil {
+		return err
+	}
+	if len(vifs) == 0 {
+		return nil
+	}
+
+	stats, err := openflow.GetVifStats(ctx, intBridge, t.OvnTables)
+	if err != nil {
+		return fmt.Errorf("%s: GetVifStats: %w", intBridge, err)
+	}
+
+	for ofport, iface := range vifs {
+		s, ok := stats[ofport]
+		if !ok {
+			// not claimed by ovn-controller
+			continue
+		}
+		labels := []string{iface.Name, iface.ExternalIDs["iface-id"]}
+		ch <- prometheus.MustNewConstMetric(
+			receivedPacketsMetric.Desc(), receivedPacketsMetric.ValueType,
+			float64(s.ReceivedPackets), labels...)
+		ch <- prometheus.MustNewConstMetric(
+			receivedBytesMetric.Desc(), receivedBytesMetric.ValueType,
+			float64(s.ReceivedBytes), labels...)
+		ch <- prometheus.MustNewConstMetric(
+			deliveredPacketsMetric.Desc(), deliveredPacketsMetric.ValueType,
+			float64(s.DeliveredPackets), labels...)
+		ch <- prometheus.MustNewConstMetric(
+			deliveredBytesMetric.Desc(), deliveredBytesMetric.ValueType,
+			float64(s.DeliveredBytes), labels...)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package vif
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib/libtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow/openflowtest"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovsdbtest"
+	"github.com/ovn-org/libovsdb/model"
+)
+
+func ofport(n int) *int {
+	return &n
+}
+
+// br-int with two VMs, a tunnel and a VM without flows, br-ex with a port
+// that has the same openflow port number as the first VM
+var rows = []model.Model{
+	&ovs.OpenvSwitch{UUID: "ovs", Bridges: []string{"brint", "brex"}},
+	&ovs.Bridge{
+		UUID: "brint", Name: "br-int",
+		Ports: []string{"vm1", "vm2", "tun", "vm3"},
+	},
+	&ovs.Bridge{UUID: "brex", Name: "br-ex", Ports: []string{"ex"}},
+	&ovs.Port{UUID: "vm1", Name: "tap1", Interfaces: []string{"vm1if"}},
+	&ovs.Port{UUID: "vm2", Name: "tap2", Interfaces: []string{"vm2if"}},
+	&ovs.Port{UUID: "tun", Name: "ovn-1a2b3c-0", Interfaces: []string{"tunif"}},
+	&ovs.Port{UUID: "vm3", Name: "tap3", Interfaces: []string{"vm3if"}},
+	&ovs.Port{UUID: "ex", Name: "eth1", Interfaces: []string{"exif"}},
+	&ovs.Interface{
+		UUID: "vm1if", Name: "tap1", Ofport: ofport(1),
+		ExternalIDs: map[string]string{"iface-id": "lp1"},
+	},
+	&ovs.Interface{
+		UUID: "vm2if", Name: "tap2", Ofport: ofport(2),
+		ExternalIDs: map[string]string{"iface-id": "lp2"},
+	},
+	&ovs.Interface{UUID: "tunif", Name: "ovn-1a2b3c-0", Type: "geneve", Ofport: ofport(3)},
+	&ovs.Interface{
+		UUID: "vm3if", Name: "tap3", Ofport: ofport(4),
+		ExternalIDs: map[string]string{"iface-id": "lp3"},
+	},
+	&ovs.Interface{
+		UUID: "exif", Name: "eth1", Ofport: ofport(1),
+		ExternalIDs: map[string]string{"iface-id": "lp-ex"},
+	},
+}
+
+var flows = []openflowtest.Flow{
+	// phy_to_log, tap1 has an untagged and a nested container vlan flow
+	{Table: 0, InPort: 1, Packets: 10, Bytes: 1000},
+	{Table: 0, InPort: 1, Packets: 2, Bytes: 200},
+	{Table: 0, InPort: 2, Packets: 5, Bytes: 500},
+	{Table: 0, InPort: 3, Packets: 7, Bytes: 700},
+	// logical pipeline, not dumped
+	{Table: 8, InPort: 1, Packets: 100, Bytes: 10000},
+	// log_to_phy
+	{Table: 65, Output: 1, Packets: 8, Bytes: 800},
+	{Table: 65, Output: 2, Packets: 4, Bytes: 400},
+	// router port, no output action
+	{Table: 65, Packets: 3, Bytes: 300},
+}
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name  string
+		sets  config.MetricSet
+		rows  []model.Model
+		flows []openflowtest.Flow
+		want  []string
+	}{
+		{
+			name:  "bound ports",
+			sets:  config.METRICS_DEFAULT,
+			rows:  rows,
+			flows: flows,
+			want: []string{
+				`ovnc_vif_delivered_bytes{iface_id="lp1",interface="tap1"} 800`,
+				`ovnc_vif_delivered_bytes{iface_id="lp2",interface="tap2"} 400`,
+				`ovnc_vif_delivered_packets{iface_id="lp1",interface="tap1"} 8`,
+				`ovnc_vif_delivered_packets{iface_id="lp2",interface="tap2"} 4`,
+				`ovnc_vif_received_bytes{iface_id="lp1",interface="tap1"} 1200`,
+				`ovnc_vif_received_bytes{iface_id="lp2",interface="tap2"} 500`,
+				`ovnc_vif_received_packets{iface_id="lp1",interface="tap1"} 12`,
+				`ovnc_vif_received_packets{iface_id="lp2",interface="tap2"} 5`,
+			},
+		},
+		{
+			name:  "no bridges",
+			sets:  config.METRICS_DEFAULT,
+			rows:  nil,
+			flows: flows,
+			want:  nil,
+		},
+		{
+			name:  "counters disabled",
+			sets:  config.METRICS_BASE,
+			rows:  rows,
+			flows: flows,
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := lib.NewTarget(config.Target{}, lib.Settings{
+				OvnTables: openflow.DefaultOvnTables,
+			})
+			target.Ovsdb = ovsdbtest.NewClient(t, tt.rows...)
+			target.Openflow = openflowtest.NewClient(t, map[string][]openflowtest.Flow{
+				"br-int": tt.flows,
+			})
+			t.Cleanup(target.Close)
+			ctx := lib.WithMetricSets(target.Bind(context.Background()), tt.sets)
+
+			got, err := libtest.Collect(ctx, Collector{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got:\n%s\nwant:\n%s",
+					strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
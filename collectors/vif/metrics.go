@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package vif
+
+import (
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var labels = []string{"interface", "iface_id"}
+
+var receivedPacketsMetric = lib.Metric{
+	Name:        "ovnc_vif_received_packets",
+	Description: "The number of packets received from a logical port by the OVN pipeline.",
+	Labels:      labels,
+	ValueType:   prometheus.CounterValue,
+	Set:         config.METRICS_COUNTERS,
+}
+
+var receivedBytesMetric = lib.Metric{
+	Name:        "ovnc_vif_received_bytes",
+	Description: "The number of bytes received from a logical port by the OVN pipeline.",
+	Labels:      labels,
+	ValueType:   prometheus.CounterValue,
+	Set:         config.METRICS_COUNTERS,
+}
+
+var deliveredPacketsMetric = lib.Metric{
+	Name:        "ovnc_vif_delivered_packets",
+	Description: "The number of packets delivered to a logical port by the OVN pipeline.",
+	Labels:      labels,
+	ValueType:   prometheus.CounterValue,
+	Set:         config.METRICS_COUNTERS,
+}
+
+var deliveredBytesMetric = lib.Metric{
+	Name:        "ovnc_vif_delivered_bytes",
+	Description: "The number of bytes delivered to a logical port by the OVN pipeline.",
+	Labels:      labels,
+	ValueType:   prometheus.CounterValue,
+	Set:         config.METRICS_COUNTERS,
+}
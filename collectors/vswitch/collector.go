@@ -5,11 +5,10 @@ package vswitch
 
 import (
 	"context"
-	"time"
+	"fmt"
 
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
-	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
 	"github.com/prometheus/client_golang/prometheus"
@@ -29,25 +28,20 @@ func (Collector) Metrics() []lib.Metric {
 	return res
 }
 
-func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
-	lib.DescribeEnabledMetrics(c, ch)
-}
-
-func (Collector) Collect(ch chan<- prometheus.Metric) {
-	if !config.MetricSets().Has(config.METRICS_BASE) {
-		return
+func (Collector) Collect(ctx context.Context, ch chan<- prometheus.Metric) error {
+	if !lib.MetricSets(ctx).Has(config.METRICS_BASE) {
+		return nil
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-	defer cancel()
 	var vswitch ovs.OpenvSwitch
 	err := ovsdb.Get(ctx, &vswitch)
 	if err != nil {
-		log.Errf("OvsdbGet(vswitch): %s", err)
-		return
+		return fmt.Errorf("OvsdbGet(vswitch): %w", err)
 	}
 
 	for _, m := range metrics {
 		value, labels := m.GetValue(&vswitch)
 		ch <- prometheus.MustNewConstMetric(m.Desc(), m.ValueType, value, labels...)
 	}
+
+	return nil
 }
@@ -4,11 +4,15 @@
 package config
 
 import (
+	"crypto/tls"
 	"fmt"
 	"log/syslog"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
 	"gopkg.in/yaml.v3"
@@ -57,52 +61,179 @@ type user struct {
 	Password string
 }
 
+// Target is a set of runtime directories of one OVS/OVN instance running on
+// the host. Empty values default to the top level settings.
+type Target struct {
+	Name        string `yaml:"name"`
+	OvsRundir   string `yaml:"ovs-rundir"`
+	OvnRundir   string `yaml:"ovn-rundir"`
+	OvsdbRundir string `yaml:"ovsdb-rundir"`
+	OvsProcdir  string `yaml:"ovs-procdir"`
+	IntBrdNam   string `yaml:"br-int-name"`
+	OvnNbRemote string `yaml:"ovn-nb-remote"`
+	OvnSbRemote string `yaml:"ovn-sb-remote"`
+}
+
+// First integration bridge table of each group of ovn-controller tables. Zero
+// values select the OVN 24.03 layout.
+type OvnTableLayout struct {
+	LogIngressPipeline   uint8 `yaml:"log-ingress-pipeline"`
+	OutputLargePktDetect uint8 `yaml:"output-large-pkt-detect"`
+	LogEgressPipeline    uint8 `yaml:"log-egress-pipeline"`
+	SaveInport           uint8 `yaml:"save-inport"`
+}
+
 type conf struct {
-	HttpListen  string            `yaml:"http-listen" env:"OPENSTACK_NETWORK_EXPORTER_HTTP_LISTEN"`
-	HttpPath    string            `yaml:"http-path" env:"OPENSTACK_NETWORK_EXPORTER_HTTP_PATH"`
-	TlsCert     string            `yaml:"tls-cert" env:"OPENSTACK_NETWORK_EXPORTER_TLS_CERT"`
-	TlsKey      string            `yaml:"tls-key" env:"OPENSTACK_NETWORK_EXPORTER_TLS_KEY"`
-	AuthUsers   []user            `yaml:"auth-users"`
-	users       map[string]string `yaml:"-"`
-	OvsRundir   string            `yaml:"ovs-rundir" env:"OPENSTACK_NETWORK_EXPORTER_OVS_RUNDIR"`
-	OvnRundir   string            `yaml:"ovn-rundir" env:"OPENSTACK_NETWORK_EXPORTER_OVN_RUNDIR"`
-	OvsdbRundir string            `yaml:"ovsdb-rundir" env:"OPENSTACK_NETWORK_EXPORTER_OVSDB_RUNDIR"`
-	OvsProcdir  string            `yaml:"ovs-procdir" env:"OPENSTACK_NETWORK_EXPORTER_OVS_PROCDIR"`
-	LogLevel    string            `yaml:"log-level" env:"OPENSTACK_NETWORK_EXPORTER_LOG_LEVEL"`
-	logLevel    syslog.Priority   `yaml:"-"`
-	Collectors  []string          `yaml:"collectors"`
-	MetricSets  []string          `yaml:"metric-sets"`
-	metricSets  MetricSet         `yaml:"-"`
-	IntBrdNam   string            `yaml:"br-int-name" env:"OPENSTACK_NETWORK_EXPORTER_BR_INT_NAME"`
-}
-
-var c = conf{
-	HttpListen:  ":1981",
-	HttpPath:    "/metrics",
-	OvsRundir:   "/run/openvswitch",
-	OvnRundir:   "/run/ovn",
-	OvsdbRundir: "/run/ovn",
-	OvsProcdir:  "/proc",
-	LogLevel:    "notice",
-	users:       make(map[string]string),
-	IntBrdNam:   "br-int",
-}
-
-func HttpListen() string           { return c.HttpListen }
-func HttpPath() string             { return c.HttpPath }
-func TlsCert() string              { return c.TlsCert }
-func TlsKey() string               { return c.TlsKey }
-func OvsRundir() string            { return c.OvsRundir }
-func OvnRundir() string            { return c.OvnRundir }
-func OvsdbRundir() string          { return c.OvsdbRundir }
-func OvsProcdir() string           { return c.OvsProcdir }
-func Collectors() []string         { return c.Collectors }
-func LogLevel() syslog.Priority    { return c.logLevel }
-func AuthUsers() map[string]string { return c.users }
-func MetricSets() MetricSet        { return c.metricSets }
-func IntBrdNam() string            { return c.IntBrdNam }
+	HttpListen         string                   `yaml:"http-listen" env:"OPENSTACK_NETWORK_EXPORTER_HTTP_LISTEN"`
+	HttpPath           string                   `yaml:"http-path" env:"OPENSTACK_NETWORK_EXPORTER_HTTP_PATH"`
+	TlsCert            string                   `yaml:"tls-cert" env:"OPENSTACK_NETWORK_EXPORTER_TLS_CERT"`
+	TlsKey             string                   `yaml:"tls-key" env:"OPENSTACK_NETWORK_EXPORTER_TLS_KEY"`
+	AuthUsers          []user                   `yaml:"auth-users"`
+	users              map[string]string        `yaml:"-"`
+	OvsRundir          string                   `yaml:"ovs-rundir" env:"OPENSTACK_NETWORK_EXPORTER_OVS_RUNDIR"`
+	OvnRundir          string                   `yaml:"ovn-rundir" env:"OPENSTACK_NETWORK_EXPORTER_OVN_RUNDIR"`
+	OvsdbRundir        string                   `yaml:"ovsdb-rundir" env:"OPENSTACK_NETWORK_EXPORTER_OVSDB_RUNDIR"`
+	OvsProcdir         string                   `yaml:"ovs-procdir" env:"OPENSTACK_NETWORK_EXPORTER_OVS_PROCDIR"`
+	OvnNbRemote        string                   `yaml:"ovn-nb-remote" env:"OPENSTACK_NETWORK_EXPORTER_OVN_NB_REMOTE"`
+	OvnSbRemote        string                   `yaml:"ovn-sb-remote" env:"OPENSTACK_NETWORK_EXPORTER_OVN_SB_REMOTE"`
+	LogLevel           string                   `yaml:"log-level" env:"OPENSTACK_NETWORK_EXPORTER_LOG_LEVEL"`
+	logLevel           syslog.Priority          `yaml:"-"`
+	Collectors         []string                 `yaml:"collectors"`
+	MetricSets         []string                 `yaml:"metric-sets"`
+	metricSets         MetricSet                `yaml:"-"`
+	IntBrdNam          string                   `yaml:"br-int-name" env:"OPENSTACK_NETWORK_EXPORTER_BR_INT_NAME"`
+	PollInterval       string                   `yaml:"poll-interval" env:"OPENSTACK_NETWORK_EXPORTER_POLL_INTERVAL"`
+	pollInterval       time.Duration            `yaml:"-"`
+	CollectorIntervals map[string]string        `yaml:"collector-intervals"`
+	collectorIntervals map[string]time.Duration `yaml:"-"`
+	ScrapeTimeout      string                   `yaml:"scrape-timeout" env:"OPENSTACK_NETWORK_EXPORTER_SCRAPE_TIMEOUT"`
+	scrapeTimeout      time.Duration            `yaml:"-"`
+	ReloadEndpoint     bool                     `yaml:"reload-endpoint" env:"OPENSTACK_NETWORK_EXPORTER_RELOAD_ENDPOINT"`
+	tlsCertificate     *tls.Certificate         `yaml:"-"`
+	Targets            []Target                 `yaml:"targets"`
+	targets            map[string]Target        `yaml:"-"`
+	TargetLabel        bool                     `yaml:"target-label" env:"OPENSTACK_NETWORK_EXPORTER_TARGET_LABEL"`
+	OvnTables          OvnTableLayout           `yaml:"ovn-tables"`
+	LflowTopN          int                      `yaml:"lflow-top-n" env:"OPENSTACK_NETWORK_EXPORTER_LFLOW_TOP_N"`
+	LflowTables        []int                    `yaml:"lflow-tables"`
+	lflowTables        []uint8                  `yaml:"-"`
+	ResolveOvnNames    bool                     `yaml:"resolve-ovn-names" env:"OPENSTACK_NETWORK_EXPORTER_RESOLVE_OVN_NAMES"`
+}
 
+// The current configuration. It is replaced as a whole when reloading so
+// that readers never see a partially updated configuration.
+var current atomic.Pointer[conf]
+
+func init() {
+	c := defaults()
+	current.Store(&c)
+}
+
+func defaults() conf {
+	return conf{
+		HttpListen:    ":1981",
+		HttpPath:      "/metrics",
+		OvsRundir:     "/run/openvswitch",
+		OvnRundir:     "/run/ovn",
+		OvsdbRundir:   "/run/ovn",
+		OvsProcdir:    "/proc",
+		LogLevel:      "notice",
+		users:         make(map[string]string),
+		IntBrdNam:     "br-int",
+		PollInterval:  "0",
+		ScrapeTimeout: "2s",
+		LflowTopN:     20,
+	}
+}
+
+func HttpListen() string           { return current.Load().HttpListen }
+func HttpPath() string             { return current.Load().HttpPath }
+func TlsCert() string              { return current.Load().TlsCert }
+func TlsKey() string               { return current.Load().TlsKey }
+func OvsRundir() string            { return current.Load().OvsRundir }
+func OvnRundir() string            { return current.Load().OvnRundir }
+func OvsdbRundir() string          { return current.Load().OvsdbRundir }
+func OvsProcdir() string           { return current.Load().OvsProcdir }
+func LogLevel() syslog.Priority    { return current.Load().logLevel }
+func AuthUsers() map[string]string { return current.Load().users }
+func IntBrdNam() string            { return current.Load().IntBrdNam }
+func ReloadEndpoint() bool         { return current.Load().ReloadEndpoint }
+
+// Return the TLS certificate loaded from the tls-cert and tls-key files.
+// Return nil if TLS is not enabled.
+func TlsCertificate() *tls.Certificate { return current.Load().tlsCertificate }
+
+// Config is a parsed configuration. Unlike the package level getters, its
+// methods keep returning the same values after a reload.
+type Config struct {
+	c *conf
+}
+
+// Return the current configuration.
+func Current() Config { return Config{current.Load()} }
+
+func (c Config) Collectors() []string         { return c.c.Collectors }
+func (c Config) MetricSets() MetricSet        { return c.c.metricSets }
+func (c Config) PollInterval() time.Duration  { return c.c.pollInterval }
+func (c Config) ScrapeTimeout() time.Duration { return c.c.scrapeTimeout }
+func (c Config) TargetLabel() bool            { return c.c.TargetLabel }
+func (c Config) OvnTables() OvnTableLayout    { return c.c.OvnTables }
+func (c Config) LflowTopN() int               { return c.c.LflowTopN }
+func (c Config) LflowTables() []uint8         { return c.c.lflowTables }
+func (c Config) ResolveOvnNames() bool        { return c.c.ResolveOvnNames }
+
+// Return the target made of the top level runtime directories. It is the one
+// scraped on the metrics HTTP path.
+func (c Config) DefaultTarget() Target {
+	return Target{
+		OvsRundir:   c.c.OvsRundir,
+		OvnRundir:   c.c.OvnRundir,
+		OvsdbRundir: c.c.OvsdbRundir,
+		OvsProcdir:  c.c.OvsProcdir,
+		IntBrdNam:   c.c.IntBrdNam,
+		OvnNbRemote: c.c.OvnNbRemote,
+		OvnSbRemote: c.c.OvnSbRemote,
+	}
+}
+
+// Return the additional targets that can be probed by name.
+func (c Config) Targets() map[string]Target { return c.c.targets }
+
+// Return the background polling intervals configured for specific
+// collectors. Other collectors are polled at the global poll interval.
+func (c Config) CollectorIntervals() map[string]time.Duration {
+	return c.c.collectorIntervals
+}
+
+// Read the configuration file and environment variables. The current
+// configuration is only replaced if the new one is valid.
 func Parse() error {
+	c, err := parse()
+	if err != nil {
+		return err
+	}
+	current.Store(c)
+	return nil
+}
+
+// Read the configuration file and environment variables and call apply with
+// the new configuration. It only becomes current if apply succeeds. Reload
+// calls must not run concurrently.
+func Reload(apply func(Config) error) error {
+	c, err := parse()
+	if err != nil {
+		return err
+	}
+	if err := apply(Config{c}); err != nil {
+		return err
+	}
+	current.Store(c)
+	return nil
+}
+
+func parse() (*conf, error) {
+	c := defaults()
+
 	path, configInEnv := os.LookupEnv("OPENSTACK_NETWORK_EXPORTER_YAML")
 	if !configInEnv {
 		path = defaultConfigPath
@@ -110,17 +241,18 @@ func Parse() error {
 
 	// parse yaml config file
 	if file, err := os.Open(path); err == nil {
+		defer file.Close()
 		dec := yaml.NewDecoder(file)
 		if err = dec.Decode(&c); err != nil {
-			return err
+			return nil, err
 		}
 	} else if configInEnv {
-		return err
+		return nil, err
 	}
 
 	// override with values from environment
 	typ := reflect.TypeOf(c)
-	val := reflect.ValueOf(c)
+	val := reflect.ValueOf(&c).Elem()
 	for i := 0; i < typ.NumField(); i++ {
 		fieldVal := val.Field(i)
 		fieldType := typ.Field(i)
@@ -132,7 +264,22 @@ func Parse() error {
 		if !found {
 			continue
 		}
-		fieldVal.SetString(envValue)
+		switch fieldVal.Kind() {
+		case reflect.Bool:
+			b, err := strconv.ParseBool(envValue)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", env, err)
+			}
+			fieldVal.SetBool(b)
+		case reflect.Int:
+			n, err := strconv.Atoi(envValue)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", env, err)
+			}
+			fieldVal.SetInt(int64(n))
+		default:
+			fieldVal.SetString(envValue)
+		}
 	}
 
 	// parse complex values
@@ -140,17 +287,101 @@ func Parse() error {
 		c.users[user.Name] = user.Password
 	}
 	if prio, err := log.ParseLogLevel(c.LogLevel); err != nil {
-		return err
+		return nil, err
 	} else {
 		c.logLevel = prio
 	}
 	if sets, err := ParseMetricSets(c.MetricSets); err != nil {
-		return err
+		return nil, err
 	} else {
 		c.metricSets = sets
 	}
+	if d, err := parseInterval(c.PollInterval); err != nil {
+		return nil, fmt.Errorf("poll-interval: %w", err)
+	} else {
+		c.pollInterval = d
+	}
+	c.collectorIntervals = make(map[string]time.Duration)
+	for name, value := range c.CollectorIntervals {
+		d, err := parseInterval(value)
+		if err != nil {
+			return nil, fmt.Errorf("collector-intervals: %s: %w", name, err)
+		}
+		if d == 0 {
+			d = c.pollInterval
+		}
+		c.collectorIntervals[name] = d
+	}
+	if d, err := parseInterval(c.ScrapeTimeout); err != nil {
+		return nil, fmt.Errorf("scrape-timeout: %w", err)
+	} else if d == 0 {
+		return nil, fmt.Errorf("scrape-timeout: must be greater than zero")
+	} else {
+		c.scrapeTimeout = d
+	}
+	if c.LflowTopN <= 0 {
+		return nil, fmt.Errorf("lflow-top-n: must be greater than zero")
+	}
+	seen := make(map[int]bool)
+	for _, t := range c.LflowTables {
+		if t < 0 || t > 254 {
+			return nil, fmt.Errorf("lflow-tables: invalid table: %d", t)
+		}
+		// each table is dumped once
+		if !seen[t] {
+			seen[t] = true
+			c.lflowTables = append(c.lflowTables, uint8(t))
+		}
+	}
+	if o := c.OvnTables; o != (OvnTableLayout{}) {
+		if o.LogIngressPipeline == 0 || o.LogIngressPipeline >= o.OutputLargePktDetect ||
+			o.OutputLargePktDetect >= o.LogEgressPipeline ||
+			o.LogEgressPipeline >= o.SaveInport {
+			return nil, fmt.Errorf("ovn-tables: tables must be set and in increasing order")
+		}
+	}
+	if c.TlsCert != "" && c.TlsKey != "" {
+		cert, err := tls.LoadX509KeyPair(c.TlsCert, c.TlsKey)
+		if err != nil {
+			return nil, fmt.Errorf("tls-cert/tls-key: %w", err)
+		}
+		c.tlsCertificate = &cert
+	}
 
-	return nil
+	c.targets = make(map[string]Target)
+	for _, t := range c.Targets {
+		if t.Name == "" {
+			return nil, fmt.Errorf("targets: missing name")
+		}
+		if _, ok := c.targets[t.Name]; ok {
+			return nil, fmt.Errorf("targets: duplicate name: %q", t.Name)
+		}
+		if t.OvsRundir == "" {
+			t.OvsRundir = c.OvsRundir
+		}
+		if t.OvnRundir == "" {
+			t.OvnRundir = c.OvnRundir
+		}
+		if t.OvsdbRundir == "" {
+			t.OvsdbRundir = c.OvsdbRundir
+		}
+		if t.OvsProcdir == "" {
+			t.OvsProcdir = c.OvsProcdir
+		}
+		if t.IntBrdNam == "" {
+			t.IntBrdNam = c.IntBrdNam
+		}
+		// the default OVN database remotes are located in ovsdb-rundir
+		if t.OvnNbRemote == "" && t.OvsdbRundir == c.OvsdbRundir {
+			t.OvnNbRemote = c.OvnNbRemote
+		}
+		if t.OvnSbRemote == "" && t.OvsdbRundir == c.OvsdbRundir {
+			t.OvnSbRemote = c.OvnSbRemote
+		}
+		c.targets[t.Name] = t
+	}
+
+	return &c, nil
 }
 
 func ParseMetricSets(names []string) (MetricSet, error) {
@@ -179,3 +410,17 @@ func ParseMetricSets(names []string) (MetricSet, error) {
 
 	return sets, nil
 }
+
+func parseInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative interval: %q", value)
+	}
+	return d, nil
+}
@@ -0,0 +1,265 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+// Package exporter provides the openstack-network-exporter HTTP handler so
+// that it can be embedded in other programs. All settings are passed
+// explicitly and each Handler owns its collectors and connections.
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	defaultScrapeTimeout       = 2 * time.Second
+	defaultMaxRequestsInFlight = 10
+	defaultLflowTopN           = 20
+)
+
+// Options configures a Handler. Zero values select the defaults.
+type Options struct {
+	// Names of the collectors to enable. Empty means all collectors. Unknown
+	// names are ignored.
+	Collectors []string
+	// Additional collectors, e.g. implemented in external modules. They
+	// are always enabled and their names must not clash with the built-in
+	// collectors.
+	ExtraCollectors []lib.Collector
+	// Metric sets to gather. Defaults to config.METRICS_DEFAULT.
+	MetricSets config.MetricSet
+	// Runtime directories of the OVS/OVN instance scraped by ServeHTTP.
+	Target config.Target
+	// Additional OVS/OVN instances scraped by Probe, indexed by name.
+	Targets map[string]config.Target
+	// Add a target="<name>" label to the metrics returned by Probe.
+	TargetLabel bool
+	// Run collectors in the background at this interval instead of on
+	// every scrape. Zero disables background polling.
+	PollInterval time.Duration
+	// Per-collector background polling intervals.
+	CollectorIntervals map[string]time.Duration
+	// Scrape time budget when the scraper does not specify one. Defaults
+	// to 2 seconds.
+	ScrapeTimeout time.Duration
+	// Maximum number of concurrent scrape requests. Defaults to 10.
+	MaxRequestsInFlight int
+	// Number of logical flows exported by the lflow collector. Defaults
+	// to 20.
+	LflowTopN int
+	// Integration bridge tables dumped by the lflow collector. Empty
+	// means the logical ingress and egress pipeline tables.
+	LflowTables []uint8
+	// Resolve the OVN router port tunnel keys to names using the
+	// Southbound database ovn-controller is connected to.
+	ResolveOvnNames bool
+	// Integration bridge tables used by ovn-controller. Defaults to
+	// openflow.DefaultOvnTables.
+	OvnTables openflow.OvnTables
+}
+
+// Handler serves scrape requests. It is safe for concurrent use. Several
+// handlers with different options can be used in the same process.
+type Handler struct {
+	opts    Options
+	all     []lib.Collector
+	enabled []lib.Collector
+	// scrape handler of the default target
+	handler http.Handler
+	// scrape handlers of the additional targets
+	probes   map[string]http.Handler
+	targets  []*lib.Target
+	inFlight chan struct{}
+	// stops the background pollers, if any
+	cancel context.CancelFunc
+}
+
+// Create a scrape handler. Close must be called to release its connections
+// and stop background polling when it is no longer used.
+func New(opts Options) (*Handler, error) {
+	if opts.MetricSets == config.METRICS_NONE {
+		opts.MetricSets = config.METRICS_DEFAULT
+	}
+	if opts.ScrapeTimeout <= 0 {
+		opts.ScrapeTimeout = defaultScrapeTimeout
+	}
+	if opts.MaxRequestsInFlight <= 0 {
+		opts.MaxRequestsInFlight = defaultMaxRequestsInFlight
+	}
+	if opts.LflowTopN <= 0 {
+		opts.LflowTopN = defaultLflowTopN
+	}
+	if opts.OvnTables == (openflow.OvnTables{}) {
+		opts.OvnTables = openflow.DefaultOvnTables
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &Handler{
+		opts:     opts,
+		all:      collectors.Collectors(),
+		probes:   make(map[string]http.Handler),
+		inFlight: make(chan struct{}, opts.MaxRequestsInFlight),
+		cancel:   cancel,
+	}
+
+	for _, c := range opts.ExtraCollectors {
+		if collectors.FindCollector(h.all, c.Name()) != nil {
+			cancel()
+			return nil, fmt.Errorf("duplicate collector name: %q", c.Name())
+		}
+		h.all = append(h.all, c)
+	}
+	for _, name := range opts.Collectors {
+		if collectors.FindCollector(h.all, name) == nil {
+			log.Warningf("unknown collector: %q", name)
+		}
+	}
+	for _, c := range h.all {
+		if h.isEnabled(c) {
+			log.Infof("registering %T", c)
+			h.enabled = append(h.enabled, c)
+		} else {
+			log.Infof("%T not registered, collector not enabled", c)
+		}
+	}
+
+	handler, err := h.newHandler(ctx, opts.Target, nil)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.handler = handler
+
+	for name, t := range opts.Targets {
+		t.Name = name
+		var labels prometheus.Labels
+		if opts.TargetLabel {
+			labels = prometheus.Labels{"target": name}
+		}
+		handler, err := h.newHandler(ctx, t, labels)
+		if err != nil {
+			h.Close()
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		h.probes[name] = handler
+	}
+
+	return h, nil
+}
+
+func (h *Handler) isEnabled(c lib.Collector) bool {
+	if len(h.opts.Collectors) == 0 {
+		return true
+	}
+	for _, name := range h.opts.Collectors {
+		if name == c.Name() {
+			return true
+		}
+	}
+	// extra collectors are always enabled
+	for _, e := range h.opts.ExtraCollectors {
+		if e == c {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) newHandler(
+	ctx context.Context, t config.Target, labels prometheus.Labels,
+) (http.Handler, error) {
+	var selector func(context.Context, collectors.Filter) prometheus.Collector
+
+	target := lib.NewTarget(t, lib.Settings{
+		LflowTopN:       h.opts.LflowTopN,
+		LflowTables:     h.opts.LflowTables,
+		ResolveOvnNames: h.opts.ResolveOvnNames,
+		OvnTables:       h.opts.OvnTables,
+	})
+	h.targets = append(h.targets, target)
+
+	if h.opts.PollInterval > 0 {
+		poller := collectors.NewPoller(h.enabled, target, h.opts.MetricSets,
+			h.opts.PollInterval, h.opts.CollectorIntervals)
+		// check for inconsistent metric descriptions once
+		if err := prometheus.NewRegistry().Register(poller); err != nil {
+			return nil, fmt.Errorf("poller: %w", err)
+		}
+		poller.Start(ctx)
+		selector = func(_ context.Context, f collectors.Filter) prometheus.Collector {
+			return poller.Select(f)
+		}
+	} else {
+		exporter := collectors.NewExporter(h.enabled, target,
+			h.opts.MetricSets, h.opts.ScrapeTimeout)
+		// check for inconsistent metric descriptions once
+		if err := prometheus.NewRegistry().Register(exporter); err != nil {
+			return nil, fmt.Errorf("collector: %w", err)
+		}
+		selector = exporter.Select
+	}
+
+	return h.scrapeHandler(selector, labels), nil
+}
+
+// Scrape the default target.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.limitInFlight(w, r, h.handler)
+}
+
+// Return a handler that scrapes the target specified by the target query
+// parameter.
+func (h *Handler) Probe() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("target")
+		if name == "" {
+			http.Error(w, "missing target parameter", http.StatusBadRequest)
+			return
+		}
+		handler, ok := h.probes[name]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unknown target: %q", name), http.StatusBadRequest)
+			return
+		}
+		h.limitInFlight(w, r, handler)
+	})
+}
+
+// Stop the background pollers and close all connections.
+func (h *Handler) Close() {
+	h.cancel()
+	for _, t := range h.targets {
+		t.Close()
+	}
+}
+
+func (h *Handler) limitInFlight(w http.ResponseWriter, r *http.Request, handler http.Handler) {
+	select {
+	case h.inFlight <- struct{}{}:
+		defer func() { <-h.inFlight }()
+		handler.ServeHTTP(w, r)
+	default:
+		http.Error(w, fmt.Sprintf(
+			"Limit of concurrent requests reached (%d), try again later.",
+			cap(h.inFlight),
+		), http.StatusServiceUnavailable)
+	}
+}
+
+func (h *Handler) promhttpOpts() promhttp.HandlerOpts {
+	return promhttp.HandlerOpts{
+		ErrorLog:          log.PrometheusLogger(),
+		ErrorHandling:     promhttp.ContinueOnError,
+		EnableOpenMetrics: true,
+	}
+}
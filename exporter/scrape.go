@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package exporter
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Leave some time for the scraper to receive the response before its own
+// deadline.
+const scrapeTimeoutOffset = 500 * time.Millisecond
+
+// Return the time budget of a scrape request. Use the timeout announced by
+// Prometheus if any, otherwise fall back to the configured default.
+func (h *Handler) scrapeTimeout(r *http.Request) time.Duration {
+	header := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds")
+	if header == "" {
+		return h.opts.ScrapeTimeout
+	}
+	seconds, err := strconv.ParseFloat(header, 64)
+	if err != nil || seconds <= 0 {
+		log.Warningf("invalid X-Prometheus-Scrape-Timeout-Seconds: %q", header)
+		return h.opts.ScrapeTimeout
+	}
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout > scrapeTimeoutOffset {
+		timeout -= scrapeTimeoutOffset
+	}
+	return timeout
+}
+
+// Parse the collectors and metric sets requested by the scraper. Collectors
+// are specified with collect[] query parameters and metric sets with set query
+// parameters.
+func (h *Handler) parseFilter(r *http.Request) (collectors.Filter, error) {
+	query := r.URL.Query()
+	names := query["collect[]"]
+	var sets []string
+	for _, value := range query["set"] {
+		for _, name := range strings.Split(value, ",") {
+			if name != "" {
+				sets = append(sets, name)
+			}
+		}
+	}
+	return collectors.ParseFilter(h.all, h.enabled, h.opts.MetricSets, names, sets)
+}
+
+// Run the selected collectors in a per-request registry so that the scrape
+// deadline is propagated to every OVS/OVN call.
+func (h *Handler) scrapeHandler(
+	selector func(context.Context, collectors.Filter) prometheus.Collector,
+	labels prometheus.Labels,
+) http.Handler {
+	opts := h.promhttpOpts()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter, err := h.parseFilter(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), h.scrapeTimeout(r))
+		defer cancel()
+
+		registry := prometheus.NewRegistry()
+		registerer := prometheus.WrapRegistererWith(labels, registry)
+		if err := registerer.Register(selector(ctx, filter)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		promhttp.HandlerFor(registry, opts).ServeHTTP(w, r)
+	})
+}
@@ -6,6 +6,7 @@ module github.com/openstack-k8s-operators/openstack-network-exporter
 go 1.21
 
 require (
+	github.com/cenkalti/backoff/v4 v4.1.3
 	github.com/go-logr/logr v1.4.1
 	github.com/ovn-org/libovsdb v0.7.0
 	github.com/prometheus/client_golang v1.20.5
@@ -14,7 +15,6 @@ require (
 
 require (
 	github.com/beorn7/perks v1.0.1 // indirect
-	github.com/cenkalti/backoff/v4 v4.1.3 // indirect
 	github.com/cenkalti/hub v1.0.1 // indirect
 	github.com/cenkalti/rpc2 v0.0.0-20210604223624-c1acbc6ec984 // indirect
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
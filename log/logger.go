@@ -9,19 +9,21 @@ import (
 	"log/syslog"
 	"os"
 	"strings"
+	"sync/atomic"
 
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 var (
-	debug     *log.Logger
-	info      *log.Logger
-	notice    *log.Logger
-	warning   *log.Logger
-	err       *log.Logger
-	crit      *log.Logger
-	verbosity syslog.Priority
+	debug   *log.Logger
+	info    *log.Logger
+	notice  *log.Logger
+	warning *log.Logger
+	err     *log.Logger
+	crit    *log.Logger
+	// accessed atomically, the log level can be changed at runtime
+	verbosity atomic.Int32
 	writer    *syslog.Writer
 )
 
@@ -50,7 +52,7 @@ func ParseLogLevel(s string) (syslog.Priority, error) {
 // Initialize the logging system.
 // Redirect messages to syslog if run by systemd.
 func InitLogging(level syslog.Priority) error {
-	verbosity = level
+	SetLevel(level)
 	if os.Getenv("INVOCATION_ID") != "" {
 		// executed by systemd
 		w, err := syslog.New(syslog.LOG_DAEMON, "")
@@ -70,13 +72,22 @@ func InitLogging(level syslog.Priority) error {
 	return nil
 }
 
+// Change the log level at runtime.
+func SetLevel(level syslog.Priority) {
+	verbosity.Store(int32(level))
+}
+
+func currentLevel() syslog.Priority {
+	return syslog.Priority(verbosity.Load())
+}
+
 func format(message string, args ...any) string {
 	return fmt.Sprintf(strings.TrimSpace(message)+"\n", args...)
 }
 
 // Write a DEBUG message to the log
 func Debugf(message string, args ...any) {
-	if verbosity < syslog.LOG_DEBUG {
+	if currentLevel() < syslog.LOG_DEBUG {
 		return
 	}
 	msg := format(message, args...)
@@ -89,7 +100,7 @@ func Debugf(message string, args ...any) {
 
 // Write an INFO message to the log
 func Infof(message string, args ...any) {
-	if verbosity < syslog.LOG_INFO {
+	if currentLevel() < syslog.LOG_INFO {
 		return
 	}
 	msg := format(message, args...)
@@ -102,7 +113,7 @@ func Infof(message string, args ...any) {
 
 // Write a NOTICE message to the log
 func Noticef(message string, args ...any) {
-	if verbosity < syslog.LOG_NOTICE {
+	if currentLevel() < syslog.LOG_NOTICE {
 		return
 	}
 	msg := format(message, args...)
@@ -115,7 +126,7 @@ func Noticef(message string, args ...any) {
 
 // Write a WARNING message to the log
 func Warningf(message string, args ...any) {
-	if verbosity < syslog.LOG_WARNING {
+	if currentLevel() < syslog.LOG_WARNING {
 		return
 	}
 	msg := format(message, args...)
@@ -128,7 +139,7 @@ func Warningf(message string, args ...any) {
 
 // Write an ERR message to the log
 func Errf(message string, args ...any) {
-	if verbosity < syslog.LOG_ERR {
+	if currentLevel() < syslog.LOG_ERR {
 		return
 	}
 	msg := format(message, args...)
@@ -145,7 +156,7 @@ func ErrorLogger() *log.Logger {
 
 // Write a CRIT message to the log
 func Critf(message string, args ...any) {
-	if verbosity < syslog.LOG_CRIT {
+	if currentLevel() < syslog.LOG_CRIT {
 		return
 	}
 	msg := format(message, args...)
@@ -166,16 +177,16 @@ func (s *sink) Enabled(level int) bool {
 	}
 	switch level {
 	case 0:
-		return verbosity >= syslog.LOG_NOTICE
+		return currentLevel() >= syslog.LOG_NOTICE
 	case 1:
-		return verbosity >= syslog.LOG_INFO
+		return currentLevel() >= syslog.LOG_INFO
 	default:
-		return verbosity >= syslog.LOG_DEBUG
+		return currentLevel() >= syslog.LOG_DEBUG
 	}
 }
 
 func (s *sink) Error(e error, msg string, args ...any) {
-	if verbosity < syslog.LOG_ERR {
+	if currentLevel() < syslog.LOG_ERR {
 		return
 	}
 	message := format("%s: %v", msg, args)
@@ -4,18 +4,18 @@
 package main
 
 import (
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"net/http"
 	"os"
-	"time"
+	"strings"
 
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/collectors/lib"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 var format = flag.String("l", "",
@@ -41,43 +41,39 @@ func main() {
 
 	log.Debugf("initializing collectors")
 
-	registry := prometheus.NewRegistry()
-
-	for _, c := range collectors.Collectors() {
-		if lib.CollectorEnabled(c) {
-			log.Infof("registering %T", c)
-
-			if err := registry.Register(c); err != nil {
-				log.Critf("collector: %s", err)
-				os.Exit(1)
-			}
-		} else {
-			log.Infof("%T not registered, metric set not enabled", c)
-		}
+	reloader, err := newReloader()
+	if err != nil {
+		log.Critf("%s", err)
+		os.Exit(1)
 	}
+	reloader.watchSignals()
+	handler := collectorPathHandler(reloader)
 
-	handler := promhttp.HandlerFor(
-		registry,
-		promhttp.HandlerOpts{
-			ErrorLog:            log.PrometheusLogger(),
-			ErrorHandling:       promhttp.ContinueOnError,
-			MaxRequestsInFlight: 10,
-			Timeout:             2 * time.Second,
-			EnableOpenMetrics:   true,
-		},
-	)
 	mux := http.NewServeMux()
 	mux.Handle(config.HttpPath(), handler)
+	if !strings.HasSuffix(config.HttpPath(), "/") {
+		// /metrics/<collector>
+		mux.Handle(config.HttpPath()+"/", handler)
+	}
+	mux.Handle("/probe", reloader.probe())
+	mux.Handle("/-/reload", reloadHandler(reloader))
 
-	var err error
 	server := http.Server{Addr: config.HttpListen(), Handler: mux, ErrorLog: log.ErrorLogger()}
 
-	if config.TlsCert() != "" && config.TlsKey() != "" {
+	if config.TlsCertificate() != nil {
 		log.Noticef("listening on https://%s%s", config.HttpListen(), config.HttpPath())
-		if len(config.AuthUsers()) > 0 {
-			server.Handler = basicAuthHandler(mux)
+		server.Handler = basicAuthHandler(mux)
+		// serve the certificate of the current configuration so that
+		// renewed TLS material is picked up on reload
+		server.TLSConfig = &tls.Config{
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				if cert := config.TlsCertificate(); cert != nil {
+					return cert, nil
+				}
+				return nil, errors.New("TLS disabled, restart required")
+			},
 		}
-		err = server.ListenAndServeTLS(config.TlsCert(), config.TlsKey())
+		err = server.ListenAndServeTLS("", "")
 	} else {
 		log.Noticef("listening on http://%s%s", config.HttpListen(), config.HttpPath())
 		err = server.ListenAndServe()
@@ -88,21 +84,45 @@ func main() {
 	}
 }
 
-func basicAuthHandler(handler http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var user, password, pass string
-		var ok, authorized bool
+const maxRequestsInFlight = 10
 
-		if user, pass, ok = r.BasicAuth(); ok {
-			if password, ok = config.AuthUsers()[user]; ok && pass == password {
-				authorized = true
-			}
+// Serve /metrics/<collector> as /metrics?collect[]=<collector>.
+func collectorPathHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base := strings.TrimSuffix(config.HttpPath(), "/")
+		if name := strings.Trim(strings.TrimPrefix(r.URL.Path, base), "/"); name != "" {
+			r = r.Clone(r.Context())
+			query := r.URL.Query()
+			query.Add("collect[]", name)
+			r.URL.RawQuery = query.Encode()
 		}
-		if authorized {
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Check the request credentials against the configured users.
+func authorized(r *http.Request) bool {
+	user, pass, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	password, ok := config.AuthUsers()[user]
+	return ok && pass == password
+}
+
+func unauthorized(w http.ResponseWriter) {
+	w.Header().Add("WWW-Authenticate", `Basic realm="ovs-node-exporter"`)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// Enforce authentication when users are configured. Users can be added or
+// removed on reload.
+func basicAuthHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(config.AuthUsers()) == 0 || authorized(r) {
 			handler.ServeHTTP(w, r)
 		} else {
-			w.Header().Add("WWW-Authenticate", `Basic realm="ovs-node-exporter"`)
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			unauthorized(w)
 		}
 	})
 }
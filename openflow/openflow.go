@@ -5,14 +5,15 @@ package openflow
 
 import (
 	"bufio"
+	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"path/filepath"
-	"time"
+	"sync"
 
-	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
 	"github.com/skydive-project/goloxi"
 	"github.com/skydive-project/goloxi/of10"
 )
@@ -28,7 +29,6 @@ const (
 	ofpttAll           uint8  = 0xff       // all tables
 	ofpstVendor        uint16 = 0xffff     // vendor stats
 	nxstAggregate      uint32 = 1          // hardcoded in a comment
-	ofTblLogToPhys     uint8  = 65
 )
 
 // struct ofp_header
@@ -73,17 +73,76 @@ type nxAggregateStatsReply struct {
 	Padding     [4]byte
 }
 
-func connect(bridge string) (net.Conn, error) {
-	sock := filepath.Join(config.OvsRundir(), bridge+".mgmt")
+// Client queries the bridges of one OVS instance over their OpenFlow
+// management sockets.
+type Client struct {
+	rundir        string
+	reachableLock sync.Mutex
+	reachable     map[string]bool
+}
+
+// Create a client for the bridge management sockets located in rundir.
+func NewClient(rundir string) *Client {
+	return &Client{
+		rundir:    rundir,
+		reachable: make(map[string]bool),
+	}
+}
+
+type clientKey struct{}
+
+// Return a copy of ctx that carries c. The package level functions connect to
+// the bridges of the client found in their context.
+func WithClient(ctx context.Context, c *Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+// Returned when no Client was attached to the context with WithClient.
+var ErrNoClient = errors.New("no openflow client in context")
+
+func clientFrom(ctx context.Context) (*Client, error) {
+	if c, ok := ctx.Value(clientKey{}).(*Client); ok {
+		return c, nil
+	}
+	return nil, ErrNoClient
+}
+
+// Return whether each bridge management socket could be reached during the
+// last connection attempt. Keys are socket names like "br-int.mgmt".
+func (c *Client) Reachable() map[string]bool {
+	c.reachableLock.Lock()
+	defer c.reachableLock.Unlock()
+	res := make(map[string]bool, len(c.reachable))
+	for sock, ok := range c.reachable {
+		res[sock] = ok
+	}
+	return res
+}
+
+// Connect to the bridge management socket. All I/O on the returned
+// connection must complete before the context deadline, if any.
+func connect(ctx context.Context, bridge string) (net.Conn, error) {
+	var d net.Dialer
 
-	conn, err := net.DialTimeout("unix", sock, 1*time.Second)
+	c, err := clientFrom(ctx)
 	if err != nil {
 		return nil, err
 	}
-	err = conn.SetDeadline(time.Now().Add(1 * time.Second))
+	sock := filepath.Join(c.rundir, bridge+".mgmt")
+
+	conn, err := d.DialContext(ctx, "unix", sock)
+	c.reachableLock.Lock()
+	c.reachable[bridge+".mgmt"] = err == nil
+	c.reachableLock.Unlock()
 	if err != nil {
 		return nil, err
 	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
 
 	return conn, nil
 }
@@ -119,8 +178,8 @@ func handShake(conn net.Conn) error {
 	return sendRecv(conn, &helloReq, &helloResp)
 }
 
-func (s *BridgeStats) GetAggregateStats() error {
-	conn, err := connect(s.Name)
+func (s *BridgeStats) GetAggregateStats(ctx context.Context) error {
+	conn, err := connect(ctx, s.Name)
 	if err != nil {
 		return err
 	}
@@ -164,18 +223,15 @@ type RouterPortsStats struct {
 	ByteCount     uint64
 }
 
-func GetRouterPortsStats() ([]RouterPortsStats, error) {
+func GetRouterPortsStats(
+	ctx context.Context, intBridge string, tables OvnTables,
+) ([]RouterPortsStats, error) {
 	var isDataPathJump bool
 	var routerStats []RouterPortsStats
 	var dpTunnK uint64
 	var pTunnK uint32
 
-	stats, err := getFlowStats(config.IntBrdNam(), ofTblLogToPhys)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, entry := range stats.GetStats() {
+	err := WalkFlowStats(ctx, intBridge, tables.LogToPhy(), func(entry *of10.NiciraFlowStats) error {
 		isDataPathJump = false
 
 		for _, anAction := range entry.GetActions() {
@@ -204,63 +260,201 @@ func GetRouterPortsStats() ([]RouterPortsStats, error) {
 					ByteCount:     entry.GetByteCount(),
 				})
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	return routerStats, nil
 }
 
-func getFlowStats(bridge string, table uint8) (*of10.NiciraFlowStatsReply, error) {
-
-	conn, err := connect(bridge)
+// Return all the flows of a bridge table. Use ofpttAll to dump all tables.
+// Large tables are better processed with WalkFlowStats.
+func GetFlowStats(ctx context.Context, bridge string, table uint8) ([]*of10.NiciraFlowStats, error) {
+	var flows []*of10.NiciraFlowStats
+	err := WalkFlowStats(ctx, bridge, table, func(f *of10.NiciraFlowStats) error {
+		flows = append(flows, f)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer conn.Close()
-	err = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	return flows, nil
+}
+
+// Call fn for each flow of a bridge table as the reply parts are received,
+// without buffering the whole dump. Stop and return the error returned by fn,
+// if any. Return ErrReplyTooLarge if the dump exceeds MaxReplySize bytes.
+func WalkFlowStats(
+	ctx context.Context, bridge string, table uint8,
+	fn func(*of10.NiciraFlowStats) error,
+) error {
+	return WalkTablesFlowStats(ctx, bridge, []uint8{table}, fn)
+}
+
+// Same as WalkFlowStats for several tables, dumped one after the other over
+// the same connection. MaxReplySize applies to each table.
+func WalkTablesFlowStats(
+	ctx context.Context, bridge string, tables []uint8,
+	fn func(*of10.NiciraFlowStats) error,
+) error {
+	conn, err := connect(ctx, bridge)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer conn.Close()
 
 	err = handShake(conn)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	request := of10.NewNiciraFlowStatsRequest()
-	request.SetXid(1)
-	request.SetTableId(table)
-	request.SetOutPort(of10.Port(ofppNone))
-	request.SetMatchLen(0)
-	encoder := goloxi.NewEncoder()
-	if err = request.Serialize(encoder); err != nil {
-		return nil, err
-	}
-	_, err = conn.Write(encoder.Bytes())
-	if err != nil {
-		return nil, err
+	reader := bufio.NewReader(conn)
+
+	for i, table := range tables {
+		request := of10.NewNiciraFlowStatsRequest()
+		request.SetXid(uint32(i + 1))
+		request.SetTableId(table)
+		request.SetOutPort(of10.Port(ofppNone))
+		request.SetMatchLen(0)
+		encoder := goloxi.NewEncoder()
+		if err = request.Serialize(encoder); err != nil {
+			return err
+		}
+		_, err = conn.Write(encoder.Bytes())
+		if err != nil {
+			return err
+		}
+
+		err = recvMultipart(reader, func(msg goloxi.Message) error {
+			reply, ok := msg.(*of10.NiciraFlowStatsReply)
+			if !ok {
+				return fmt.Errorf("unexpected openflow response of type %T from bridge", msg)
+			}
+			for _, f := range reply.GetStats() {
+				if err := fn(f); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
 	}
 
-	reader := bufio.NewReader(conn)
+	return nil
+}
+
+// Maximum number of bytes accepted for all the parts of a stats reply.
+const MaxReplySize = 64 << 20
+
+// Returned when a stats reply exceeds MaxReplySize.
+var ErrReplyTooLarge = errors.New("openflow reply too large")
+
+// Read and decode one openflow message. Return ErrReplyTooLarge without
+// reading it if the message is larger than limit bytes.
+func recvMsg(reader *bufio.Reader, limit int) (goloxi.Message, int, error) {
 	data, err := reader.Peek(8)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	header := &goloxi.Header{}
 	if err := header.Decode(goloxi.NewDecoder(data)); err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+	if int(header.Length) > limit {
+		return nil, 0, ErrReplyTooLarge
 	}
 	data = make([]byte, header.Length)
 	_, err = io.ReadFull(reader, data)
+	if err != nil {
+		return nil, 0, err
+	}
+	msg, err := of10.DecodeMessage(data)
+	return msg, len(data), err
+}
+
+// Read all the parts of a stats reply until the one without the "more" flag
+// and call fn for each of them.
+func recvMultipart(reader *bufio.Reader, fn func(goloxi.Message) error) error {
+	remain := MaxReplySize
+
+	for {
+		msg, size, err := recvMsg(reader, remain)
+		if err != nil {
+			return err
+		}
+		remain -= size
+		reply, ok := msg.(of10.IStatsReply)
+		if !ok {
+			return fmt.Errorf("unexpected openflow response of type %T from bridge", msg)
+		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+		if reply.GetFlags()&of10.OFPSFReplyMore == 0 {
+			return nil
+		}
+	}
+}
+
+type TableStats struct {
+	TableId      uint8
+	Name         string
+	MaxEntries   uint32
+	ActiveCount  uint32
+	LookupCount  uint64
+	MatchedCount uint64
+}
+
+// Return the statistics of all the tables of a bridge. The entries of all
+// reply parts are merged.
+func GetTableStats(ctx context.Context, bridge string) ([]TableStats, error) {
+	conn, err := connect(ctx, bridge)
 	if err != nil {
 		return nil, err
 	}
-	flows, err := of10.DecodeMessage(data)
+	defer conn.Close()
+
+	err = handShake(conn)
 	if err != nil {
 		return nil, err
 	}
-	switch t := flows.(type) {
-	case *of10.NiciraFlowStatsReply:
-		return t, nil
-	default:
-		return nil, fmt.Errorf("unexpected openflow response of type %T from bridge", t)
+
+	request := of10.NewTableStatsRequest()
+	request.SetXid(1)
+	encoder := goloxi.NewEncoder()
+	if err = request.Serialize(encoder); err != nil {
+		return nil, err
 	}
+	_, err = conn.Write(encoder.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []TableStats
+
+	err = recvMultipart(bufio.NewReader(conn), func(msg goloxi.Message) error {
+		reply, ok := msg.(*of10.TableStatsReply)
+		if !ok {
+			return fmt.Errorf("unexpected openflow response of type %T from bridge", msg)
+		}
+		for _, e := range reply.GetEntries() {
+			tables = append(tables, TableStats{
+				TableId:      e.GetTableId(),
+				Name:         e.GetName(),
+				MaxEntries:   e.GetMaxEntries(),
+				ActiveCount:  e.GetActiveCount(),
+				LookupCount:  e.GetLookupCount(),
+				MatchedCount: e.GetMatchedCount(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tables, nil
 }
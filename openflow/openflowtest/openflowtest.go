@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+// Package openflowtest provides fake bridges that answer openflow flow stats
+// requests with canned flows so that collectors can be tested without a
+// running ovs-vswitchd.
+package openflowtest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow"
+	"github.com/skydive-project/goloxi"
+	"github.com/skydive-project/goloxi/of10"
+)
+
+// Flow is one openflow rule of a fake bridge.
+type Flow struct {
+	Table   uint8
+	Cookie  uint64
+	Packets uint64
+	Bytes   uint64
+	// Port matched with in_port, if not zero.
+	InPort uint16
+	// Port of an output action, if not zero.
+	Output uint16
+}
+
+// Start fake bridges in a temporary runtime directory. bridges is indexed by
+// bridge name. Return a client connected to their management sockets. Flow
+// dumps are answered with one reply part per flow. The bridges are stopped
+// when the test ends.
+func NewClient(t testing.TB, bridges map[string][]Flow) *openflow.Client {
+	t.Helper()
+
+	// t.TempDir() paths can exceed the maximum unix socket path length
+	rundir, err := os.MkdirTemp("", "openflow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(rundir) })
+
+	for name, flows := range bridges {
+		l, err := net.Listen("unix", filepath.Join(rundir, name+".mgmt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { l.Close() })
+		go serve(l, flows)
+	}
+
+	return openflow.NewClient(rundir)
+}
+
+func serve(l net.Listener, flows []Flow) {
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		} else if err != nil {
+			continue
+		}
+		go handle(conn, flows)
+	}
+}
+
+func handle(conn net.Conn, flows []Flow) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return
+		}
+		data := make([]byte, binary.BigEndian.Uint16(header[2:4]))
+		copy(data, header)
+		if _, err := io.ReadFull(reader, data[len(header):]); err != nil {
+			return
+		}
+
+		msg, err := of10.DecodeMessage(data)
+		if err != nil {
+			return
+		}
+		switch msg := msg.(type) {
+		case *of10.Hello:
+			// echo the hello back as the version negotiation
+			_, err = conn.Write(data)
+		case *of10.NiciraFlowStatsRequest:
+			err = replyFlows(conn, msg, flows)
+		default:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func replyFlows(conn net.Conn, request *of10.NiciraFlowStatsRequest, flows []Flow) error {
+	var selected []Flow
+	for _, f := range flows {
+		if request.GetTableId() == 0xff || request.GetTableId() == f.Table {
+			selected = append(selected, f)
+		}
+	}
+
+	// an empty dump is still answered with one reply part
+	for i := 0; i == 0 || i < len(selected); i++ {
+		reply := of10.NewNiciraFlowStatsReply()
+		reply.SetXid(request.GetXid())
+		if i < len(selected)-1 {
+			reply.SetFlags(of10.OFPSFReplyMore)
+		}
+		encoder := goloxi.NewEncoder()
+		if err := reply.Serialize(encoder); err != nil {
+			return err
+		}
+		data := encoder.Bytes()
+
+		if i < len(selected) {
+			stats, err := serializeFlow(selected[i])
+			if err != nil {
+				return err
+			}
+			data = append(data, stats...)
+			binary.BigEndian.PutUint16(data[2:4], uint16(len(data)))
+		}
+
+		if _, err := conn.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func serializeFlow(f Flow) ([]byte, error) {
+	stats := of10.NewNiciraFlowStats()
+	stats.SetTableId(f.Table)
+	stats.SetCookie(f.Cookie)
+	stats.SetPacketCount(f.Packets)
+	stats.SetByteCount(f.Bytes)
+	if f.InPort != 0 {
+		inPort := of10.NewNxmInPort()
+		inPort.Value = of10.Port(f.InPort)
+		stats.Match.NxmEntries = append(stats.Match.NxmEntries, inPort)
+		// 4 bytes header and 2 bytes value, padded to 8 bytes
+		stats.SetMatchLen(6)
+	}
+
+	// goloxi Serialize methods write the object length at the start of
+	// the encoder, each object needs one of its own
+	encoder := goloxi.NewEncoder()
+	if err := stats.Serialize(encoder); err != nil {
+		return nil, err
+	}
+	data := encoder.Bytes()
+
+	if f.Output != 0 {
+		output := of10.NewActionOutput()
+		output.Port = of10.Port(f.Output)
+		encoder = goloxi.NewEncoder()
+		if err := output.Serialize(encoder); err != nil {
+			return nil, err
+		}
+		data = append(data, encoder.Bytes()...)
+		binary.BigEndian.PutUint16(data[0:2], uint16(len(data)))
+	}
+
+	return data, nil
+}
@@ -0,0 +1,178 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package openflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/skydive-project/goloxi/of10"
+)
+
+// Physical tables used by ovn-controller on the integration bridge. The table
+// numbers are the OFTABLE_* definitions of controller/lflow.h, they change
+// between OVN releases. Only the first table of each group is configurable,
+// the tables of a group are assumed to keep their relative order.
+type OvnTables struct {
+	// First table of the logical ingress pipeline.
+	LogIngressPipeline uint8
+	// First table following the logical ingress pipeline. It is followed
+	// by output_large_pkt_process, remote_output, local_output and
+	// check_loopback.
+	OutputLargePktDetect uint8
+	// First table of the logical egress pipeline.
+	LogEgressPipeline uint8
+	// First table following the logical egress pipeline. It is followed
+	// by log_to_phy, mac_binding, etc.
+	SaveInport uint8
+}
+
+// Integration bridge layout of OVN 24.03.
+var DefaultOvnTables = OvnTables{
+	LogIngressPipeline:   8,
+	OutputLargePktDetect: 37,
+	LogEgressPipeline:    42,
+	SaveInport:           64,
+}
+
+// phy_to_log is always the first table.
+const ofTablePhyToLog = 0
+
+// Tables starting at OutputLargePktDetect.
+var ovnOutputStages = []string{
+	"output_large_pkt_detect",
+	"output_large_pkt_process",
+	"remote_output",
+	"local_output",
+	"check_loopback",
+}
+
+// Tables starting at SaveInport.
+var ovnSaveInportStages = []string{
+	"save_inport",
+	"log_to_phy",
+	"mac_binding",
+	"mac_lookup",
+	"chk_lb_hairpin",
+	"chk_lb_hairpin_reply",
+	"ct_snat_hairpin",
+	"get_fdb",
+	"lookup_fdb",
+	"chk_in_port_sec",
+	"chk_in_port_sec_nd",
+	"chk_out_port_sec",
+	"ecmp_nh_mac",
+	"ecmp_nh",
+	"chk_lb_affinity",
+	"mac_cache_use",
+	"ct_zone_lookup",
+}
+
+// Return the log_to_phy table, where packets are delivered to their output
+// port.
+func (o OvnTables) LogToPhy() uint8 {
+	return o.SaveInport + 1
+}
+
+// Return the tables of the logical ingress and egress pipelines. The cookie of
+// the openflow rules of other tables is not a logical flow UUID.
+func (o OvnTables) LogicalPipelines() []uint8 {
+	var tables []uint8
+	for t := o.LogIngressPipeline; t < o.OutputLargePktDetect; t++ {
+		tables = append(tables, t)
+	}
+	for t := o.LogEgressPipeline; t < o.SaveInport; t++ {
+		tables = append(tables, t)
+	}
+	return tables
+}
+
+// Return the OVN pipeline stage implemented by an integration bridge table.
+// Logical flow tables are shared by switches and routers, they are named
+// after the logical table number, e.g. "ingress_3" for table 11.
+func (o OvnTables) Stage(table uint8) string {
+	switch {
+	case table == ofTablePhyToLog:
+		return "phy_to_log"
+	case table >= o.LogIngressPipeline && table < o.OutputLargePktDetect:
+		return fmt.Sprintf("ingress_%d", table-o.LogIngressPipeline)
+	case table >= o.OutputLargePktDetect && table < o.LogEgressPipeline:
+		if i := int(table - o.OutputLargePktDetect); i < len(ovnOutputStages) {
+			return ovnOutputStages[i]
+		}
+	case table >= o.LogEgressPipeline && table < o.SaveInport:
+		return fmt.Sprintf("egress_%d", table-o.LogEgressPipeline)
+	case table >= o.SaveInport:
+		if i := int(table - o.SaveInport); i < len(ovnSaveInportStages) {
+			return ovnSaveInportStages[i]
+		}
+	}
+	return ""
+}
+
+// Traffic of an integration bridge port at the boundaries of the OVN
+// pipeline.
+type VifStats struct {
+	OfPort uint16
+	// Packets received from the port in the phy_to_log table. They may
+	// be dropped later by the logical pipeline.
+	ReceivedPackets uint64
+	ReceivedBytes   uint64
+	// Packets delivered to the port with an output action in the
+	// log_to_phy table.
+	DeliveredPackets uint64
+	DeliveredBytes   uint64
+}
+
+// Return the traffic counters of the integration bridge ports that have
+// flows in the phy_to_log or log_to_phy tables, indexed by openflow port
+// number. Both tables are dumped over the same connection.
+func GetVifStats(
+	ctx context.Context, intBridge string, tables OvnTables,
+) (map[uint16]*VifStats, error) {
+	stats := make(map[uint16]*VifStats)
+	get := func(port of10.Port) *VifStats {
+		s, ok := stats[uint16(port)]
+		if !ok {
+			s = &VifStats{OfPort: uint16(port)}
+			stats[uint16(port)] = s
+		}
+		return s
+	}
+
+	logToPhy := tables.LogToPhy()
+
+	err := WalkTablesFlowStats(ctx, intBridge, []uint8{ofTablePhyToLog, logToPhy},
+		func(f *of10.NiciraFlowStats) error {
+			switch f.GetTableId() {
+			case ofTablePhyToLog:
+				// one or more flows per port matching on in_port
+				// (more than one with nested containers vlans)
+				for _, m := range f.GetMatch().NxmEntries {
+					if inPort, ok := m.(*of10.NxmInPort); ok {
+						s := get(inPort.Value)
+						s.ReceivedPackets += f.GetPacketCount()
+						s.ReceivedBytes += f.GetByteCount()
+						break
+					}
+				}
+			case logToPhy:
+				// local ports are reached with an output action,
+				// router and remote ports use other actions
+				for _, a := range f.GetActions() {
+					if output, ok := a.(*of10.ActionOutput); ok {
+						s := get(output.Port)
+						s.DeliveredPackets += f.GetPacketCount()
+						s.DeliveredBytes += f.GetByteCount()
+					}
+				}
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
@@ -5,53 +5,232 @@ package ovsdb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
-	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/cenkalti/backoff/v4"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
 	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
 	"github.com/ovn-org/libovsdb/client"
 	"github.com/ovn-org/libovsdb/model"
-	"github.com/ovn-org/libovsdb/ovsdb"
+	libovsdb "github.com/ovn-org/libovsdb/ovsdb"
 )
 
-var (
-	ovsdbLock  sync.Mutex
-	ovsdbConn  client.Client
-	ovsdbModel model.DatabaseModel
+// Returned by Get and List while the connection to ovsdb-server is being
+// re-established in the background.
+var ErrNotConnected = errors.New("not connected to ovsdb-server, reconnecting")
+
+// Returned when no Client was attached to the context with WithClient.
+var ErrNoClient = errors.New("no ovsdb client in context")
+
+// Returned when the Client was closed.
+var ErrClosed = errors.New("ovsdb client closed")
+
+const (
+	reconnectTimeout     = 5 * time.Second
+	reconnectMinInterval = 500 * time.Millisecond
+	reconnectMaxInterval = 30 * time.Second
 )
 
-func connect(ctx context.Context) (client.Client, error) {
-	ovsdbLock.Lock()
-	defer ovsdbLock.Unlock()
+// Health of the connection to ovsdb-server.
+type Status struct {
+	// True if a connection was established at least once.
+	Initialized bool
+	// Current connection state.
+	Connected bool
+	// Number of times the connection was lost.
+	Disconnects uint64
+	// Number of times the connection was successfully re-established.
+	Reconnects uint64
+}
+
+// Client maintains a connection to a database, by default the Open_vSwitch
+// database of one OVS instance. The connection is established on first use.
+// Monitored tables are read with Get and List, other tables with Select.
+type Client struct {
+	endpoints  []string
+	dbModel    func() (model.ClientDBModel, error)
+	monitorAll bool
+	// tables that are monitored on first use, see NewRemoteClient
+	tables map[string]model.Model
+	// tables monitored so far, monitors are re-created on reconnection
+	monitored   []string
+	ssl         *SSLFiles
+	lock        sync.Mutex
+	conn        client.Client
+	reachable   *bool
+	connected   bool
+	disconnects uint64
+	reconnects  uint64
+	closed      bool
+	// used to trigger a reconnection when a disconnection notification
+	// from libovsdb was missed
+	kick chan struct{}
+	// closed by Close to stop watching the connection
+	done chan struct{}
+}
+
+// Create a client for the db.sock socket located in rundir.
+func NewClient(rundir string) *Client {
+	return &Client{
+		endpoints:  []string{fmt.Sprintf("unix:%s/db.sock", rundir)},
+		dbModel:    ovs.FullDatabaseModel,
+		monitorAll: true,
+		kick:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+}
+
+// Create a client for a database that may be too large to be monitored as a
+// whole, such as the OVN Northbound and Southbound databases. remote is a comma
+// separated list of OVSDB remotes, as in the ovn-remote external id. ssl is
+// required for "ssl:" remotes. Only the given tables can be read with Get and
+// List. Each of them is monitored the first time it is read, the tables that
+// are never read do not use any memory. Select can be used for all tables.
+func NewRemoteClient(
+	database, remote string, ssl *SSLFiles, tables map[string]model.Model,
+) *Client {
+	var endpoints []string
+	for _, e := range strings.Split(remote, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			endpoints = append(endpoints, e)
+		}
+	}
+	return &Client{
+		endpoints: endpoints,
+		dbModel: func() (model.ClientDBModel, error) {
+			return model.NewClientDBModel(database, tables)
+		},
+		tables: tables,
+		ssl:    ssl,
+		kick:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+// Return the remotes of the client, separated by commas.
+func (c *Client) Endpoint() string {
+	return strings.Join(c.endpoints, ",")
+}
+
+// Return the endpoint name as reported in health metrics: the socket file
+// name for unix endpoints and the full remote otherwise.
+func (c *Client) Name() string {
+	if len(c.endpoints) == 1 {
+		if path, ok := strings.CutPrefix(c.endpoints[0], "unix:"); ok {
+			return filepath.Base(path)
+		}
+	}
+	return c.Endpoint()
+}
+
+type clientKey struct{}
+
+// Return a copy of ctx that carries c. Get and List read from the database of
+// the client found in their context.
+func WithClient(ctx context.Context, c *Client) context.Context {
+	return context.WithValue(ctx, clientKey{}, c)
+}
+
+func clientFrom(ctx context.Context) (*Client, error) {
+	if c, ok := ctx.Value(clientKey{}).(*Client); ok {
+		return c, nil
+	}
+	return nil, ErrNoClient
+}
+
+func (c *Client) GetStatus() Status {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Status{
+		Initialized: c.conn != nil,
+		Connected:   c.connected,
+		Disconnects: c.disconnects,
+		Reconnects:  c.reconnects,
+	}
+}
+
+func (c *Client) setReachable(ok bool) {
+	c.lock.Lock()
+	c.reachable = &ok
+	c.lock.Unlock()
+}
 
-	if ovsdbConn != nil {
-		return ovsdbConn, nil
+// Return whether the ovsdb-server endpoint could be reached during the last
+// transaction. The second value is false if no transaction was attempted.
+func (c *Client) Reachable() (bool, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.reachable == nil {
+		return false, false
 	}
+	return *c.reachable, true
+}
 
-	endpoint := fmt.Sprintf("unix:%s/db.sock", config.OvsRundir())
+// Stop reconnecting and close the connection, if any.
+func (c *Client) Close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	close(c.done)
+	if c.conn != nil {
+		c.conn.Close()
+	}
+}
 
-	log.Debugf("connecting to ovsdb: %s", endpoint)
+func (c *Client) connect(ctx context.Context) (client.Client, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
-	schema, err := ovs.FullDatabaseModel()
+	if c.closed {
+		return nil, ErrClosed
+	}
+	if c.conn != nil {
+		if !c.connected {
+			return nil, ErrNotConnected
+		}
+		if c.conn.CurrentEndpoint() == "" {
+			// libovsdb dropped the connection but we missed the
+			// notification, force a reconnection
+			select {
+			case c.kick <- struct{}{}:
+			default:
+			}
+			return nil, ErrNotConnected
+		}
+		return c.conn, nil
+	}
+
+	log.Debugf("connecting to ovsdb: %s", c.Endpoint())
+
+	schema, err := c.dbModel()
 	if err != nil {
 		log.Errf("NewOVSDBClient: %s", err)
 		return nil, err
 	}
-	mod, errs := model.NewDatabaseModel(ovs.Schema(), schema)
-	if len(errs) > 0 {
-		for _, err = range errs {
-			log.Errf("model.NewDatabaseModel: %s", err)
+
+	opts := []client.Option{client.WithLogger(log.OvsdbLogger())}
+	for _, e := range c.endpoints {
+		opts = append(opts, client.WithEndpoint(e))
+	}
+	if c.ssl != nil {
+		config, err := c.ssl.tlsConfig()
+		if err != nil {
+			log.Errf("NewOVSDBClient: %s", err)
+			return nil, err
 		}
-		return nil, err
+		opts = append(opts, client.WithTLSConfig(config))
 	}
-
-	db, err := client.NewOVSDBClient(
-		schema,
-		client.WithEndpoint(endpoint),
-		client.WithLogger(log.OvsdbLogger()),
-	)
+	db, err := client.NewOVSDBClient(schema, opts...)
 	if err != nil {
 		log.Errf("NewOVSDBClient: %s", err)
 		return nil, err
@@ -60,86 +239,252 @@ func connect(ctx context.Context) (client.Client, error) {
 		log.Errf("db.Connect: %s", err)
 		return nil, err
 	}
+	if err = c.monitor(ctx, db, c.monitored); err != nil {
+		db.Close()
+		return nil, err
+	}
 
-	ovsdbModel = mod
-	ovsdbConn = db
+	c.conn = db
+	c.connected = true
+
+	go c.watchConnection(db)
 
 	return db, nil
 }
 
-func Get(ctx context.Context, result model.Model) error {
-	db, err := connect(ctx)
-	if err != nil {
-		log.Errf("connect: %s", err)
-		return err
+// Subscribe to all changes in the given tables, or in the whole database for
+// db.sock. Once the initial dump is received, the libovsdb cache is kept up to
+// date by ovsdb-server and Get/List are served from memory without any
+// transaction.
+func (c *Client) monitor(ctx context.Context, db client.Client, tables []string) error {
+	if c.monitorAll {
+		if _, err := db.MonitorAll(ctx); err != nil {
+			return fmt.Errorf("db.MonitorAll: %w", err)
+		}
+		return nil
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+	var opts []client.MonitorOption
+	for _, name := range tables {
+		opts = append(opts, client.WithTable(c.tables[name]))
 	}
+	if _, err := db.Monitor(ctx, db.NewMonitor(opts...)); err != nil {
+		return fmt.Errorf("db.Monitor: %w", err)
+	}
+	return nil
+}
 
-	info, err := ovsdbModel.NewModelInfo(result)
-	if err != nil {
-		log.Errf("NewModelInfo: %s", err)
-		return err
+// Monitor the table of the typ model if it is not monitored yet.
+func (c *Client) monitorTable(ctx context.Context, db client.Client, typ reflect.Type) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.monitorAll {
+		return nil
 	}
-	res, err := db.Transact(ctx, ovsdb.Operation{
-		Op:    ovsdb.OperationSelect,
-		Table: info.Metadata.TableName,
-	})
-	if err != nil {
-		log.Errf("Transact: %s", err)
+	table := ""
+	for name, m := range c.tables {
+		if reflect.TypeOf(m).Elem() == typ {
+			table = name
+			break
+		}
+	}
+	if table == "" {
+		return fmt.Errorf("%s: no such table in client model", typ)
+	}
+	if slices.Contains(c.monitored, table) {
+		return nil
+	}
+	if err := c.monitor(ctx, db, []string{table}); err != nil {
 		return err
 	}
-	for _, r := range res {
-		for _, row := range r.Rows {
-			err = info.SetField("_uuid", row["_uuid"].(ovsdb.UUID).GoUUID)
-			if err != nil {
-				log.Errf("info.SetField: %s", err)
+	c.monitored = append(c.monitored, table)
+
+	return nil
+}
+
+// Wait for the connection to be lost and re-establish it with an exponential
+// backoff. Get and List fail immediately with ErrNotConnected in the meantime.
+func (c *Client) watchConnection(db client.Client) {
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-db.DisconnectNotify():
+		case <-c.kick:
+			if db.CurrentEndpoint() != "" {
+				// stale kick queued before the previous
+				// reconnection, db.Connect would return nil
+				// without doing anything
+				continue
+			}
+		}
+		select {
+		case <-c.done:
+			// disconnected by Close
+			return
+		default:
+		}
+
+		c.lock.Lock()
+		c.connected = false
+		c.disconnects++
+		tables := slices.Clone(c.monitored)
+		c.lock.Unlock()
+
+		log.Warningf("ovsdb: %s: connection lost, reconnecting", c.Endpoint())
+
+		b := backoff.NewExponentialBackOff()
+		b.InitialInterval = reconnectMinInterval
+		b.MaxInterval = reconnectMaxInterval
+		b.MaxElapsedTime = 0 // retry forever
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			select {
+			case <-c.done:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		err := backoff.RetryNotify(func() error {
+			ctx, cancel := context.WithTimeout(ctx, reconnectTimeout)
+			defer cancel()
+			if err := db.Connect(ctx); err != nil {
 				return err
 			}
-			return ovsdbModel.Mapper.GetRowData(&row, info) //nolint: staticcheck // the surrounding loop is unconditionally terminated
+			// monitors are dropped by libovsdb on disconnection
+			return c.monitor(ctx, db, tables)
+		}, backoff.WithContext(b, ctx), func(err error, next time.Duration) {
+			log.Debugf("ovsdb: reconnect failed: %s, retrying in %s", err, next)
+		})
+		cancel()
+		if err != nil {
+			// client closed
+			return
 		}
+
+		c.lock.Lock()
+		c.connected = true
+		c.reconnects++
+		c.lock.Unlock()
+
+		log.Noticef("ovsdb: %s: reconnected", c.Endpoint())
 	}
-	return client.ErrNotFound
 }
 
-func List[T model.Model](ctx context.Context, results *[]T) error {
-	db, err := connect(ctx)
+// Fill result with the first row of its table in the database of the client
+// found in the context. This is intended for tables
+// that contain exactly one row such as Open_vSwitch.
+func Get(ctx context.Context, result model.Model) error {
+	c, err := clientFrom(ctx)
 	if err != nil {
-		log.Errf("connect: %s", err)
 		return err
 	}
+	db, err := c.connect(ctx)
+	if err != nil {
+		c.setReachable(false)
+		return fmt.Errorf("connect: %w", err)
+	}
+	c.setReachable(true)
 
-	var t T
+	val := reflect.ValueOf(result)
+	if val.Kind() != reflect.Pointer {
+		return fmt.Errorf("expected pointer to model, got %T", result)
+	}
+	if err := c.monitorTable(ctx, db, val.Type().Elem()); err != nil {
+		return err
+	}
+	rows := reflect.New(reflect.SliceOf(val.Type().Elem()))
+	if err := db.List(ctx, rows.Interface()); err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	if rows.Elem().Len() == 0 {
+		return client.ErrNotFound
+	}
+	val.Elem().Set(rows.Elem().Index(0))
 
-	info, err := ovsdbModel.NewModelInfo(&t)
+	return nil
+}
+
+// Append all rows of the table associated with T to results. Rows are read
+// from the monitor cache, the table is monitored on first use.
+func List[T model.Model](ctx context.Context, results *[]T) error {
+	c, err := clientFrom(ctx)
 	if err != nil {
-		log.Errf("NewModelInfo: %s", err)
 		return err
 	}
+	return ListFrom(ctx, c, results)
+}
 
-	res, err := db.Transact(ctx, ovsdb.Operation{
-		Op:    ovsdb.OperationSelect,
-		Table: info.Metadata.TableName,
-	})
+// Same as List but read from the database of c instead of the client found in
+// the context.
+func ListFrom[T model.Model](ctx context.Context, c *Client, results *[]T) error {
+	db, err := c.connect(ctx)
 	if err != nil {
-		log.Errf("Transact: %s", err)
+		c.setReachable(false)
+		return fmt.Errorf("connect: %w", err)
+	}
+	c.setReachable(true)
+
+	if err := c.monitorTable(ctx, db, reflect.TypeOf((*T)(nil)).Elem()); err != nil {
 		return err
 	}
 
-	for _, r := range res {
-		for _, row := range r.Rows {
-			var value T
-			info, _ = ovsdbModel.NewModelInfo(&value)
-			err = ovsdbModel.Mapper.GetRowData(&row, info)
-			if err != nil {
-				log.Errf("Mapper.GetRowData: %s", err)
-				return err
-			}
-			err = info.SetField("_uuid", row["_uuid"].(ovsdb.UUID).GoUUID)
-			if err != nil {
-				log.Errf("info.SetField: %s", err)
-				return err
-			}
-			*results = append(*results, value)
-		}
+	// libovsdb only fills the slice up to its capacity, use a fresh one
+	var rows []T
+	if err := db.List(ctx, &rows); err != nil {
+		return fmt.Errorf("cache: %w", err)
 	}
+	*results = append(*results, rows...)
+
 	return nil
 }
+
+// Return the rows of table that match all conditions with a select
+// transaction. Only the specified columns are returned, all of them if none
+// are specified. This is intended for tables that are not monitored.
+func (c *Client) Select(
+	ctx context.Context, table string, columns []string,
+	where ...libovsdb.Condition,
+) ([]libovsdb.Row, error) {
+	db, err := c.connect(ctx)
+	if err != nil {
+		c.setReachable(false)
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+
+	if where == nil {
+		// an empty where clause matches all rows
+		where = []libovsdb.Condition{}
+	}
+	op := libovsdb.Operation{
+		Op:      libovsdb.OperationSelect,
+		Table:   table,
+		Where:   where,
+		Columns: columns,
+	}
+	results, err := db.Transact(ctx, op)
+	if errors.Is(err, client.ErrNotConnected) {
+		// libovsdb may not have noticed the disconnection yet, the
+		// next connect will trigger a reconnection
+		c.setReachable(false)
+		return nil, fmt.Errorf("select(%s): %w", table, err)
+	}
+	c.setReachable(true)
+	if err != nil {
+		return nil, fmt.Errorf("select(%s): %w", table, err)
+	}
+	if len(results) != 1 {
+		return nil, fmt.Errorf("select(%s): unexpected number of results: %d",
+			table, len(results))
+	}
+	if results[0].Error != "" {
+		return nil, fmt.Errorf("select(%s): %s: %s",
+			table, results[0].Error, results[0].Details)
+	}
+
+	return results[0].Rows, nil
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+// Package ovsdbtest runs in-memory database servers so that collectors can be
+// tested without a running ovsdb-server.
+package ovsdbtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/ovsdb/ovs"
+	"github.com/ovn-org/libovsdb/client"
+	"github.com/ovn-org/libovsdb/database/inmemory"
+	"github.com/ovn-org/libovsdb/model"
+	"github.com/ovn-org/libovsdb/server"
+
+	libovsdb "github.com/ovn-org/libovsdb/ovsdb"
+)
+
+// Start an Open_vSwitch database server in a temporary runtime directory and
+// insert rows in a single transaction. Rows may reference each other with
+// named UUIDs (e.g. UUID: "br0"). Rows that are not referenced by the root
+// Open_vSwitch row may be garbage collected. Return a client connected to the
+// server. The server is stopped when the test ends.
+func NewClient(t testing.TB, rows ...model.Model) *ovsdb.Client {
+	t.Helper()
+
+	clientModel, err := ovs.FullDatabaseModel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := Serve(t, ovs.Schema(), clientModel, rows...)
+
+	c := ovsdb.NewClient(filepath.Dir(strings.TrimPrefix(remote, "unix:")))
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+// Start a server for the database described by schema in a temporary runtime
+// directory and insert rows in a single transaction. The row types must be
+// part of clientModel. Rows of non-root tables that are not referenced may be
+// garbage collected. Return the remote of the server, e.g.
+// "unix:/tmp/ovsdb123/db.sock". The server is stopped when the test ends.
+func Serve(
+	t testing.TB, schema libovsdb.DatabaseSchema,
+	clientModel model.ClientDBModel, rows ...model.Model,
+) string {
+	t.Helper()
+
+	// t.TempDir() paths can exceed the maximum unix socket path length
+	rundir, err := os.MkdirTemp("", "ovsdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(rundir) })
+
+	dbModel, errs := model.NewDatabaseModel(schema, clientModel)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	db := inmemory.NewDatabase(map[string]model.ClientDBModel{
+		clientModel.Name(): clientModel,
+	})
+	srv, err := server.NewOvsdbServer(db, dbModel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sockpath := filepath.Join(rundir, "db.sock")
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.Serve("unix", sockpath) }()
+	for !srv.Ready() {
+		select {
+		case err := <-errCh:
+			t.Fatal(err)
+		case <-time.After(time.Millisecond):
+		}
+	}
+	t.Cleanup(srv.Close)
+
+	remote := "unix:" + sockpath
+	if len(rows) > 0 {
+		insert(t, clientModel, remote, rows)
+	}
+
+	return remote
+}
+
+func insert(t testing.TB, clientModel model.ClientDBModel, endpoint string, rows []model.Model) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c, err := client.NewOVSDBClient(clientModel, client.WithEndpoint(endpoint))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Connect(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ops, err := c.Create(rows...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := c.Transact(ctx, ops...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := libovsdb.CheckOperationResults(reply, ops); err != nil {
+		t.Fatal(err)
+	}
+}
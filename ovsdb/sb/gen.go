@@ -0,0 +1,12 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+// Package sb contains the libovsdb model of the OVN_Southbound database. To
+// limit the amount of data received from the database, schema.json is
+// a subset of ovn-sb.ovsschema that only contains the tables and columns used
+// by the exporter.
+package sb
+
+import _ "github.com/ovn-org/libovsdb/modelgen"
+
+//go:generate go run github.com/ovn-org/libovsdb/cmd/modelgen -o . -p sb schema.json
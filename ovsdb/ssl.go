@@ -0,0 +1,69 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package ovsdb
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Files used to authenticate "ssl:" connections, as configured in the SSL
+// table of the Open_vSwitch database.
+type SSLFiles struct {
+	PrivateKey  string
+	Certificate string
+	CACert      string
+}
+
+// Load the certificates. Like OVS, the peer certificate is verified against
+// the CA certificate but its host name is not checked.
+func (f *SSLFiles) tlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(f.Certificate, f.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := os.ReadFile(f.CACert)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(buf) {
+		return nil, fmt.Errorf("%s: no certificate found", f.CACert)
+	}
+
+	verify := func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("no peer certificate")
+		}
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: x509.NewCertPool(),
+		}
+		var leaf *x509.Certificate
+		for i, raw := range rawCerts {
+			c, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			if i == 0 {
+				leaf = c
+			} else {
+				opts.Intermediates.AddCert(c)
+			}
+		}
+		_, err := leaf.Verify(opts)
+		return err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		// host names are not verified, VerifyPeerCertificate checks
+		// the certificate chain instead
+		InsecureSkipVerify:    true,
+		VerifyPeerCertificate: verify,
+	}, nil
+}
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright (c) 2024 Robin Jarry
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/openstack-k8s-operators/openstack-network-exporter/config"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/exporter"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/log"
+	"github.com/openstack-k8s-operators/openstack-network-exporter/openflow"
+)
+
+// Build the exporter options from a parsed configuration.
+func options(c config.Config) exporter.Options {
+	return exporter.Options{
+		Collectors:          c.Collectors(),
+		MetricSets:          c.MetricSets(),
+		Target:              c.DefaultTarget(),
+		Targets:             c.Targets(),
+		TargetLabel:         c.TargetLabel(),
+		PollInterval:        c.PollInterval(),
+		CollectorIntervals:  c.CollectorIntervals(),
+		ScrapeTimeout:       c.ScrapeTimeout(),
+		MaxRequestsInFlight: maxRequestsInFlight,
+		OvnTables:           openflow.OvnTables(c.OvnTables()),
+		LflowTopN:           c.LflowTopN(),
+		LflowTables:         c.LflowTables(),
+		ResolveOvnNames:     c.ResolveOvnNames(),
+	}
+}
+
+// A handler and the requests it is serving.
+type handlerRef struct {
+	handler *exporter.Handler
+	users   sync.WaitGroup
+}
+
+// Serves scrape requests with the current handler. The handler is replaced on
+// reload, in-flight requests complete with the previous one which is closed
+// once they are all done.
+type reloader struct {
+	// serializes reloads
+	lock sync.Mutex
+	// protects current
+	refLock sync.RWMutex
+	current *handlerRef
+}
+
+func newReloader() (*reloader, error) {
+	h, err := exporter.New(options(config.Current()))
+	if err != nil {
+		return nil, err
+	}
+	return &reloader{current: &handlerRef{handler: h}}, nil
+}
+
+// Return the current handler. users.Done must be called once the request has
+// been served.
+func (r *reloader) acquire() *handlerRef {
+	r.refLock.RLock()
+	defer r.refLock.RUnlock()
+	r.current.users.Add(1)
+	return r.current
+}
+
+func (r *reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ref := r.acquire()
+	defer ref.users.Done()
+	ref.handler.ServeHTTP(w, req)
+}
+
+// Serve scrape requests for the target specified in the query parameters.
+func (r *reloader) probe() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ref := r.acquire()
+		defer ref.users.Done()
+		ref.handler.Probe().ServeHTTP(w, req)
+	})
+}
+
+// Re-read the configuration file and environment. If the new configuration is
+// invalid or the handler cannot be created from it, the current configuration
+// and handler are kept and an error is returned.
+func (r *reloader) reload() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	listen := config.HttpListen()
+	path := config.HttpPath()
+	tls := config.TlsCertificate() != nil
+
+	var h *exporter.Handler
+	err := config.Reload(func(c config.Config) error {
+		var err error
+		h, err = exporter.New(options(c))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	log.SetLevel(config.LogLevel())
+
+	if config.HttpListen() != listen || config.HttpPath() != path ||
+		(config.TlsCertificate() != nil) != tls {
+		log.Warningf("http-listen, http-path and enabling or disabling TLS " +
+			"are only applied after a restart")
+	}
+
+	r.refLock.Lock()
+	prev := r.current
+	r.current = &handlerRef{handler: h}
+	r.refLock.Unlock()
+
+	// no new request can acquire the previous handler at this point
+	go func() {
+		prev.users.Wait()
+		prev.handler.Close()
+	}()
+
+	log.Noticef("configuration reloaded")
+
+	return nil
+}
+
+// Reload the configuration every time SIGHUP is received.
+func (r *reloader) watchSignals() {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+
+	go func() {
+		for range sig {
+			log.Noticef("SIGHUP received, reloading configuration")
+			if err := r.reload(); err != nil {
+				log.Errf("reload: %s, keeping previous configuration", err)
+			}
+		}
+	}()
+}
+
+// Reload the configuration on POST requests. The endpoint must be enabled
+// in the configuration and always requires authentication.
+func reloadHandler(r *reloader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !config.ReloadEndpoint() {
+			http.NotFound(w, req)
+			return
+		}
+		if req.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed),
+				http.StatusMethodNotAllowed)
+			return
+		}
+		if !authorized(req) {
+			unauthorized(w)
+			return
+		}
+		if err := r.reload(); err != nil {
+			log.Errf("reload: %s, keeping previous configuration", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintln(w, "configuration reloaded")
+	})
+}